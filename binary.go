@@ -0,0 +1,101 @@
+package confless
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	cborDecMode = func() cbor.DecMode {
+		mode, err := cbor.DecOptions{
+			DefaultMapType: reflect.TypeOf(map[string]any(nil)),
+		}.DecMode()
+		if err != nil {
+			panic(err)
+		}
+
+		return mode
+	}()
+)
+
+// Decodes a CBOR document into generic data.
+// Maps must have string keys, byte strings are kept as bytes (loaded into []byte or string fields).
+func decodeCBOR(r io.Reader) (any, error) {
+	var data any
+	err := cborDecMode.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return binaryToData(data)
+}
+
+// Decodes a MessagePack document into generic data.
+// Maps must have string keys, binary data is kept as bytes (loaded into []byte or string fields).
+func decodeMsgPack(r io.Reader) (any, error) {
+	dec := msgpack.NewDecoder(r)
+	dec.SetMapDecoder(func(dec *msgpack.Decoder) (any, error) {
+		return dec.DecodeUntypedMap()
+	})
+
+	var data any
+	err := dec.Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return binaryToData(data)
+}
+
+// Converts decoded binary data to generic data (maps with string keys, slices, bytes and basic values).
+func binaryToData(data any) (any, error) {
+	switch d := data.(type) {
+	case []any:
+		values := make([]any, 0, len(d))
+		for _, v := range d {
+			value, err := binaryToData(v)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return values, nil
+	case map[string]any:
+		values := make(map[string]any, len(d))
+		for k, v := range d {
+			value, err := binaryToData(v)
+			if err != nil {
+				return nil, err
+			}
+
+			values[k] = value
+		}
+
+		return values, nil
+	case map[any]any:
+		values := make(map[string]any, len(d))
+		for k, v := range d {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("unsupported key %v: keys must be strings", k)
+			}
+
+			value, err := binaryToData(v)
+			if err != nil {
+				return nil, err
+			}
+
+			values[key] = value
+		}
+
+		return values, nil
+	default:
+		return data, nil
+	}
+}
@@ -0,0 +1,111 @@
+package confless
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	// Supported compression codecs.
+	codecs = []codec{
+		{
+			name:       "gzip",
+			extensions: []string{".gz", ".gzip"},
+			magic: func(head []byte) bool {
+				return bytes.HasPrefix(head, []byte{0x1f, 0x8b})
+			},
+			open: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+		},
+		{
+			name:       "bzip2",
+			extensions: []string{".bz2"},
+			magic: func(head []byte) bool {
+				// Followed by the block size ("1" to "9").
+				return len(head) >= 4 && bytes.HasPrefix(head, []byte("BZh")) && head[3] >= '1' && head[3] <= '9'
+			},
+			open: func(r io.Reader) (io.ReadCloser, error) {
+				return io.NopCloser(bzip2.NewReader(r)), nil
+			},
+		},
+		{
+			name:       "zlib",
+			extensions: []string{".zz", ".zlib"},
+			magic: func(head []byte) bool {
+				// Deflate with a 32K window followed by the usual flags of the compression levels (never printable text).
+				return len(head) >= 2 && head[0] == 0x78 && (head[1] == 0x01 || head[1] == 0x9c || head[1] == 0xda)
+			},
+			open: func(r io.Reader) (io.ReadCloser, error) {
+				return zlib.NewReader(r)
+			},
+		},
+		{
+			// Not supported by the standard library, but common for large generated files (e.g. "allowlist.yaml.zst").
+			name:       "zstd",
+			extensions: []string{".zst", ".zstd"},
+			magic: func(head []byte) bool {
+				return bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd})
+			},
+			open: func(r io.Reader) (io.ReadCloser, error) {
+				dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+				if err != nil {
+					return nil, err
+				}
+
+				return dec.IOReadCloser(), nil
+			},
+		},
+	}
+)
+
+type codec struct {
+	name       string
+	extensions []string
+	magic      func(head []byte) bool
+	open       func(r io.Reader) (io.ReadCloser, error)
+}
+
+// Returns a reader decompressing the file with the given path if compressed and the path without the compression extension.
+// The codec is detected by the extension (e.g. "config.json.gz") or the magic bytes of the content.
+func decompress(r io.Reader, path string) (io.ReadCloser, string, error) {
+	lower := strings.ToLower(path)
+
+	// Detect the codec by the extension.
+	for _, c := range codecs {
+		for _, ext := range c.extensions {
+			if strings.HasSuffix(lower, ext) {
+				rc, err := c.open(r)
+				if err != nil {
+					return nil, "", fmt.Errorf("failed to decompress %s: %w", c.name, err)
+				}
+
+				return rc, path[:len(path)-len(ext)], nil
+			}
+		}
+	}
+
+	// Detect the codec by the magic bytes.
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	for _, c := range codecs {
+		if c.magic(head) {
+			rc, err := c.open(br)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to decompress %s: %w", c.name, err)
+			}
+
+			return rc, path, nil
+		}
+	}
+
+	return io.NopCloser(br), path, nil
+}
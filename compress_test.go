@@ -0,0 +1,173 @@
+package confless
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compressed "name: MyApp\n" (bzip2 has no writer in the standard library).
+var bzip2Content = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x57, 0xdb,
+	0x2e, 0x32, 0x00, 0x00, 0x04, 0x5d, 0x80, 0x00, 0x10, 0x40, 0x00, 0x00,
+	0x10, 0x20, 0x02, 0x22, 0x03, 0x40, 0x20, 0x20, 0x00, 0x31, 0x00, 0xd3,
+	0x4d, 0x04, 0x00, 0x1e, 0xa7, 0x46, 0x14, 0x06, 0xef, 0x1e, 0x2e, 0xe4,
+	0x8a, 0x70, 0xa1, 0x20, 0xaf, 0xb6, 0x5c, 0x64,
+}
+
+// Returns the content compressed by gzip.
+func gzipContent(content string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// Returns the content compressed by zlib with the given level.
+func zlibContent(content string, level int) []byte {
+	var buf bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&buf, level)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+
+	return buf.Bytes()
+}
+
+// Returns the content compressed by zstd.
+func zstdContent(content string) []byte {
+	w, _ := zstd.NewWriter(nil)
+	defer func() { _ = w.Close() }()
+
+	return w.EncodeAll([]byte(content), nil)
+}
+
+func Test_decompress(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  []byte
+		wantPath string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "gzip by extension",
+			path:     "config.json.gz",
+			content:  gzipContent("name: MyApp\n"),
+			wantPath: "config.json",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "gzip by magic bytes",
+			path:     "config.yaml",
+			content:  gzipContent("name: MyApp\n"),
+			wantPath: "config.yaml",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "bzip2 by extension",
+			path:     "config.yaml.bz2",
+			content:  bzip2Content,
+			wantPath: "config.yaml",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "bzip2 by magic bytes",
+			path:     "config",
+			content:  bzip2Content,
+			wantPath: "config",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "zlib by extension",
+			path:     "config.json.zz",
+			content:  zlibContent("name: MyApp\n", zlib.DefaultCompression),
+			wantPath: "config.json",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "zlib by magic bytes",
+			path:     "config",
+			content:  zlibContent("name: MyApp\n", zlib.BestCompression),
+			wantPath: "config",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "zlib without compression by magic bytes",
+			path:     "config",
+			content:  zlibContent("name: MyApp\n", zlib.NoCompression),
+			wantPath: "config",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "zstd by extension with uppercase",
+			path:     "config.yaml.ZST",
+			content:  zstdContent("name: MyApp\n"),
+			wantPath: "config.yaml",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "zstd by magic bytes",
+			path:     "config.yaml",
+			content:  zstdContent("name: MyApp\n"),
+			wantPath: "config.yaml",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "uncompressed",
+			path:     "config.yaml",
+			content:  []byte("name: MyApp\n"),
+			wantPath: "config.yaml",
+			want:     "name: MyApp\n",
+		},
+		{
+			name:     "uncompressed text similar to bzip2",
+			path:     "config.properties",
+			content:  []byte("BZhost=localhost"),
+			wantPath: "config.properties",
+			want:     "BZhost=localhost",
+		},
+		{
+			name:     "uncompressed text similar to zlib",
+			path:     "config.properties",
+			content:  []byte("x^=1"),
+			wantPath: "config.properties",
+			want:     "x^=1",
+		},
+		{
+			name:    "invalid gzip",
+			path:    "config.json.gz",
+			content: []byte(`{"name": "MyApp"}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, path, err := decompress(bytes.NewReader(tt.content), tt.path)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("decompress() failed: %v", err)
+				}
+				return
+			}
+			defer func() { _ = r.Close() }()
+			if tt.wantErr {
+				t.Fatal("decompress() succeeded unexpectedly")
+			}
+
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if path != tt.wantPath || string(got) != tt.want {
+				t.Errorf("decompress() = %q, %q, want %q, %q", got, path, tt.want, tt.wantPath)
+			}
+		})
+	}
+}
@@ -14,8 +14,9 @@ func Configure(opts ...loaderOption) {
 
 // Register an environment variable prefix to load.
 // Names are converted to dot-separated paths (e.g. "MY_FLAG" -> "my.flag").
-func RegisterEnv(pre string) {
-	defaultLoader.RegisterEnv(pre)
+// Multiple prefixes can be registered, later ones take precedence.
+func RegisterEnv(pre string, opts ...envOption) {
+	defaultLoader.RegisterEnv(pre, opts...)
 }
 
 // Register a file to load.
@@ -23,11 +24,44 @@ func RegisterFile(path string, opts ...fileOption) {
 	defaultLoader.RegisterFile(path, opts...)
 }
 
+// Register a file format by its name and file extensions (e.g. "cue" with ".cue") for all loaders.
+// The format can be selected by WithFileFormat or the format tag option, or is detected by the extensions.
+// Registering the name of a built-in format replaces it.
+func RegisterFormat(name string, extensions []string, factory DecoderFactory) {
+	defaultFormats.register(name, extensions, decoderFunc(factory))
+}
+
+// Register a directory to load with one file per key (e.g. Kubernetes secret volumes).
+// File names are converted to dot-separated paths by the separator (e.g. "database.host").
+func RegisterDir(path string, opts ...dirOption) {
+	defaultLoader.RegisterDir(path, opts...)
+}
+
+// Register the systemd credentials directory to load (see LoadCredential= of systemd.exec).
+// The directory is located by the $CREDENTIALS_DIRECTORY environment variable, credentials are skipped if unset.
+// Credential names are converted to dot-separated paths by the separator (e.g. "database.password").
+func RegisterCredentials(opts ...dirOption) {
+	defaultLoader.RegisterCredentials(opts...)
+}
+
+// Register an environment variable containing a whole configuration document to load (e.g. "APP_CONFIG").
+// The format is detected from the content unless set explicitly, documents are loaded after the files by default.
+func RegisterEnvDocument(name string, opts ...docOption) {
+	defaultLoader.RegisterEnvDocument(name, opts...)
+}
+
+// Register a dotenv file to load (e.g. ".env").
+// Its variables are mapped by the registered environment variable prefixes, the environment takes precedence.
+// The process environment is not modified.
+func RegisterDotEnv(path string, opts ...dotEnvOption) {
+	defaultLoader.RegisterDotEnv(path, opts...)
+}
+
 // Register the flags to load.
 // Names are converted to dot-separated paths (e.g. "my-flag" -> "my.flag").
 // Note that flags must be parsed before loading.
-func RegisterFlags(f *flag.FlagSet) {
-	defaultLoader.RegisterFlags(f)
+func RegisterFlags(f *flag.FlagSet, opts ...flagOption) {
+	defaultLoader.RegisterFlags(f, opts...)
 }
 
 // Populate the given object by applying the registered sources.
@@ -0,0 +1,271 @@
+package confless
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parses the entries of a dotenv document into "KEY=VALUE" pairs (in order of appearance).
+// Supports comments, the export keyword, single and double quotes, escapes and multi-line values in double quotes.
+// References to variables (e.g. "${HOME}" or "$HOME") in unquoted and double-quoted values
+// are expanded by previous entries or the given lookup function.
+func parseDotEnv(r io.Reader, lookup func(name string) (string, bool)) ([]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	p := &dotEnvParser{
+		src:    strings.ReplaceAll(string(b), "\r\n", "\n"),
+		line:   1,
+		values: make(map[string]string),
+		lookup: lookup,
+	}
+
+	entries := make([]string, 0)
+	for {
+		key, value, ok, err := p.next()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+		if !ok {
+			break
+		}
+
+		p.values[key] = value
+		entries = append(entries, key+"="+value)
+	}
+
+	return entries, nil
+}
+
+type dotEnvParser struct {
+	src    string
+	pos    int
+	line   int
+	values map[string]string
+	lookup func(name string) (string, bool)
+}
+
+// Returns the next entry of the document.
+func (p *dotEnvParser) next() (string, string, bool, error) {
+	for {
+		p.skip(" \t")
+		if p.pos >= len(p.src) {
+			return "", "", false, nil
+		}
+
+		// Skip empty lines and comments.
+		switch p.src[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+			continue
+		case '#':
+			p.skipLine()
+			continue
+		}
+
+		break
+	}
+
+	// Skip the export keyword.
+	if strings.HasPrefix(p.src[p.pos:], "export ") || strings.HasPrefix(p.src[p.pos:], "export\t") {
+		p.pos += len("export")
+		p.skip(" \t")
+	}
+
+	// Read the key.
+	start := p.pos
+	for p.pos < len(p.src) && isDotEnvKeyChar(p.src[p.pos]) {
+		p.pos++
+	}
+	key := p.src[start:p.pos]
+	if key == "" {
+		return "", "", false, fmt.Errorf("invalid key")
+	}
+
+	p.skip(" \t")
+	if p.pos >= len(p.src) || p.src[p.pos] != '=' {
+		return "", "", false, fmt.Errorf("missing '=' after %s", key)
+	}
+	p.pos++
+	p.skip(" \t")
+
+	// Read the value.
+	var value string
+	var err error
+	switch {
+	case p.pos < len(p.src) && p.src[p.pos] == '\'':
+		value, err = p.singleQuoted()
+	case p.pos < len(p.src) && p.src[p.pos] == '"':
+		value, err = p.doubleQuoted()
+	default:
+		value = p.unquoted()
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid value of %s: %w", key, err)
+	}
+
+	// Only a comment may follow the value.
+	p.skip(" \t")
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '#' {
+		return "", "", false, fmt.Errorf("unexpected character after value of %s: %q", key, p.src[p.pos])
+	}
+	p.skipLine()
+
+	return key, value, true, nil
+}
+
+// Reads a single-quoted value without escapes and references.
+func (p *dotEnvParser) singleQuoted() (string, error) {
+	end := strings.IndexByte(p.src[p.pos+1:], '\'')
+	if end < 0 {
+		return "", fmt.Errorf("missing closing quote")
+	}
+
+	value := p.src[p.pos+1 : p.pos+1+end]
+	p.line += strings.Count(value, "\n")
+	p.pos += end + 2
+
+	return value, nil
+}
+
+// Reads a double-quoted value with escapes and references.
+func (p *dotEnvParser) doubleQuoted() (string, error) {
+	var sb strings.Builder
+
+	p.pos++
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				return "", fmt.Errorf("missing closing quote")
+			}
+
+			switch e := p.src[p.pos+1]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+
+			p.pos += 2
+		case '$':
+			sb.WriteString(p.reference())
+		default:
+			if c == '\n' {
+				p.line++
+			}
+
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", fmt.Errorf("missing closing quote")
+}
+
+// Reads an unquoted value until the end of the line or an inline comment.
+func (p *dotEnvParser) unquoted() string {
+	var sb strings.Builder
+
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		c := p.src[p.pos]
+
+		// Comments start with whitespace followed by "#".
+		if c == '#' && (sb.Len() == 0 || strings.ContainsRune(" \t", rune(p.src[p.pos-1]))) {
+			break
+		}
+
+		if c == '$' {
+			sb.WriteString(p.reference())
+			continue
+		}
+
+		sb.WriteByte(c)
+		p.pos++
+	}
+
+	return strings.TrimRight(sb.String(), " \t")
+}
+
+// Reads a reference to a variable (e.g. "${HOME}" or "$HOME") and returns its value.
+// A "$" not followed by a name is kept as is.
+func (p *dotEnvParser) reference() string {
+	p.pos++
+
+	// Read the name in braces.
+	if p.pos < len(p.src) && p.src[p.pos] == '{' {
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return "$"
+		}
+
+		name := p.src[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return p.resolve(name)
+	}
+
+	// Read the plain name.
+	start := p.pos
+	for p.pos < len(p.src) && isDotEnvKeyChar(p.src[p.pos]) && p.src[p.pos] != '.' {
+		p.pos++
+	}
+	if start == p.pos {
+		return "$"
+	}
+
+	return p.resolve(p.src[start:p.pos])
+}
+
+// Returns the value of the variable with the given name (empty if unset).
+func (p *dotEnvParser) resolve(name string) string {
+	if value, ok := p.values[name]; ok {
+		return value
+	}
+
+	if p.lookup != nil {
+		if value, ok := p.lookup(name); ok {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// Skips the given characters.
+func (p *dotEnvParser) skip(chars string) {
+	for p.pos < len(p.src) && strings.IndexByte(chars, p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// Skips the rest of the line including the line break.
+func (p *dotEnvParser) skipLine() {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		p.pos = len(p.src)
+		return
+	}
+
+	p.pos += end + 1
+	p.line++
+}
+
+// Checks whether the character is allowed in keys.
+func isDotEnvKeyChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
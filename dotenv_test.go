@@ -0,0 +1,102 @@
+package confless
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseDotEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		env     map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "parse plain values",
+			src:  "NAME=MyApp\nPORT = 8080\n",
+			want: []string{"NAME=MyApp", "PORT=8080"},
+		},
+		{
+			name: "skip comments and empty lines",
+			src:  "# comment\n\n  # indented comment\nNAME=MyApp # inline comment\nURL=http://host/#anchor\n",
+			want: []string{"NAME=MyApp", "URL=http://host/#anchor"},
+		},
+		{
+			name: "skip export keyword",
+			src:  "export NAME=MyApp\n",
+			want: []string{"NAME=MyApp"},
+		},
+		{
+			name: "keep single-quoted values literally",
+			src:  `NAME='My App \n ${HOME}' # comment`,
+			env:  map[string]string{"HOME": "/root"},
+			want: []string{`NAME=My App \n ${HOME}`},
+		},
+		{
+			name: "unescape double-quoted values",
+			src:  `NAME="My \"App\"\n\t\$HOME"`,
+			want: []string{"NAME=My \"App\"\n\t$HOME"},
+		},
+		{
+			name: "parse multi-line values",
+			src:  "KEY=\"-----BEGIN KEY-----\nabc\n-----END KEY-----\"\nNAME=MyApp\n",
+			want: []string{"KEY=-----BEGIN KEY-----\nabc\n-----END KEY-----", "NAME=MyApp"},
+		},
+		{
+			name: "expand references",
+			src:  "HOST=localhost\nURL=\"http://${HOST}:$PORT/$USER\"\nPLAIN=${HOST}-$UNSET\n",
+			env:  map[string]string{"PORT": "8080", "USER": "admin"},
+			want: []string{"HOST=localhost", "URL=http://localhost:8080/admin", "PLAIN=localhost-"},
+		},
+		{
+			name: "keep dollar without name",
+			src:  "PRICE=5$\n",
+			want: []string{"PRICE=5$"},
+		},
+		{
+			name: "parse windows line endings",
+			src:  "NAME=MyApp\r\nPORT=8080\r\n",
+			want: []string{"NAME=MyApp", "PORT=8080"},
+		},
+		{
+			name:    "error for missing closing quote",
+			src:     "NAME=\"MyApp\n",
+			wantErr: true,
+		},
+		{
+			name:    "error for missing equal sign",
+			src:     "NAME\n",
+			wantErr: true,
+		},
+		{
+			name:    "error for characters after quoted value",
+			src:     "NAME=\"My\"App\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := func(name string) (string, bool) {
+				value, ok := tt.env[name]
+				return value, ok
+			}
+
+			got, gotErr := parseDotEnv(strings.NewReader(tt.src), lookup)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("parseDotEnv() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("parseDotEnv() succeeded unexpectedly")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDotEnv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
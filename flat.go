@@ -0,0 +1,96 @@
+package confless
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"gopkg.in/ini.v1"
+
+	"github.com/codetent/confless/pkg/dotpath"
+)
+
+// Decodes an INI document into values by their dot-separated paths.
+// Keys of sections are prefixed by the section name (e.g. "host" in "[database]" -> "database.host").
+func decodeINI(r io.Reader) (pathValues, error) {
+	f, err := ini.Load(r)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(pathValues)
+	for _, section := range f.Sections() {
+		prefix := ""
+		if section.Name() != ini.DefaultSection {
+			prefix = section.Name() + "."
+		}
+
+		for _, key := range section.Keys() {
+			values[prefix+key.Name()] = key.String()
+		}
+	}
+
+	return values, nil
+}
+
+// Decodes a Java properties document into values by their dot-separated paths.
+func decodeProperties(r io.Reader) (pathValues, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	p, err := properties.Load(b, properties.UTF8)
+	if err != nil {
+		return nil, err
+	}
+
+	return pathValues(p.Map()), nil
+}
+
+// Populate the object by the values at the given paths (in sorted order, indexes compared numerically).
+// Values are converted the same way as environment variables.
+// Unknown paths and paths that cannot be resolved (e.g. below basic values or with gaps in indexes) are skipped.
+// Nil pointers along the paths are allocated and slices are extended by consecutive indexes (e.g. "servers.0.host").
+func populateByPaths(obj any, values pathValues, opts ...dotpath.Option) error {
+	for _, path := range slices.SortedFunc(maps.Keys(values), comparePaths) {
+		value := values[path]
+
+		// Skip unknown and unresolvable paths.
+		field, err := dotpath.Resolve(obj, path, opts...)
+		if err != nil {
+			continue
+		}
+
+		err = setNamedValue(field, path, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compares the dot-separated paths part by part, numeric parts by their value (e.g. "a.2" < "a.10").
+func comparePaths(a string, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(pa), len(pb)) {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+
+		c := strings.Compare(pa[i], pb[i])
+		if errA == nil && errB == nil {
+			c = cmp.Compare(na, nb)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(pa), len(pb))
+}
@@ -0,0 +1,80 @@
+package confless
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_comparePaths(t *testing.T) {
+	paths := []string{"a.10", "b", "a.2.x", "a.2", "A", "a.b", "a.1"}
+	want := []string{"A", "a.1", "a.2", "a.2.x", "a.10", "a.b", "b"}
+
+	slices.SortFunc(paths, comparePaths)
+	if !slices.Equal(paths, want) {
+		t.Errorf("comparePaths() sorted = %v, want %v", paths, want)
+	}
+}
+
+func Test_populateByPaths_DuplicateKeys(t *testing.T) {
+	values := pathValues{
+		"Database.Host": "upper",
+		"database.host": "lower",
+		"DATABASE.HOST": "screaming",
+	}
+
+	// Keys resolving to the same field are applied in sorted order, so the last one always wins.
+	for range 20 {
+		obj := &struct {
+			Database struct {
+				Host string
+			}
+		}{}
+
+		err := populateByPaths(obj, values)
+		if err != nil {
+			t.Fatalf("populateByPaths() failed: %v", err)
+		}
+		if obj.Database.Host != "lower" {
+			t.Fatalf("expected Database.Host to be 'lower', got '%s'", obj.Database.Host)
+		}
+	}
+}
+
+func Test_populateByPaths_ValueNotInError(t *testing.T) {
+	obj := &struct {
+		Database struct {
+			Port int
+		}
+	}{}
+
+	err := populateByPaths(obj, pathValues{"database.port": "s3cret"})
+	if err == nil {
+		t.Fatal("populateByPaths() succeeded unexpectedly")
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("expected error not to contain the value, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "database.port") {
+		t.Errorf("expected error to contain the path, got: %v", err)
+	}
+}
+
+func Test_populateByPaths_UnknownPathsNotAllocated(t *testing.T) {
+	type DB struct {
+		Host string
+	}
+
+	obj := &struct {
+		DB    *DB
+		Items []DB
+	}{}
+
+	err := populateByPaths(obj, pathValues{"db.unknown": "1", "items.0.unknown": "1"})
+	if err != nil {
+		t.Fatalf("populateByPaths() failed: %v", err)
+	}
+	if obj.DB != nil || len(obj.Items) != 0 {
+		t.Errorf("expected no allocation, got %+v and %+v", obj.DB, obj.Items)
+	}
+}
@@ -0,0 +1,253 @@
+package confless
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/codetent/confless/pkg/dotpath"
+)
+
+var (
+	defaultFormats = newFormatRegistry(nil)
+
+	// Formats detected by the content (in order of detection).
+	sniffedFormats = []string{string(FileFormatJSON), string(FileFormatTOML), string(FileFormatYAML)}
+
+	tomlKey          = `([\w-]+|"[^"]*"|'[^']*')`
+	tomlTablePattern = regexp.MustCompile(`^\[\[?\s*` + tomlKey + `(\s*\.\s*` + tomlKey + `)*\s*\]\]?\s*(#.*)?$`)
+	tomlKeyPattern   = regexp.MustCompile(`^` + tomlKey + `(\s*\.\s*` + tomlKey + `)*\s*=`)
+	yamlKeyPattern   = regexp.MustCompile(`^[^\s:#\[\]{},][^:]*:(\s|$)`)
+)
+
+// Decodes documents into generic data (maps, slices and basic values).
+// Decoders like *json.Decoder or *yaml.Decoder satisfy this interface.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Creates a decoder reading from the given reader.
+type DecoderFactory func(r io.Reader) Decoder
+
+// Decodes a document with the given file name (used in error messages) into generic data for the given type.
+// Flat formats return values by their paths (see pathValues).
+type decodeFunc func(r io.Reader, filename string, t reflect.Type, opts ...dotpath.Option) (any, error)
+
+// Values by their dot-separated paths decoded from flat formats (e.g. INI).
+type pathValues map[string]string
+
+// Documents of multi-document formats (e.g. YAML) applied as successive layers.
+type documents []any
+
+// Selects the documents of multi-document files by the value of a discriminator key.
+type profile struct {
+	key   string
+	value string
+}
+
+type format struct {
+	extensions []string
+	decode     decodeFunc
+}
+
+type formatRegistry struct {
+	mu      sync.RWMutex
+	parent  *formatRegistry
+	formats map[string]*format
+	names   []string
+}
+
+func init() {
+	defaultFormats.register(string(FileFormatJSON), []string{".json"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeJSON(r, "json")
+	})
+	defaultFormats.register(string(FileFormatJSONC), []string{".jsonc"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeJSON(r, "jsonc")
+	})
+	defaultFormats.register(string(FileFormatJSON5), []string{".json5"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeJSON(r, "json5")
+	})
+	defaultFormats.register(string(FileFormatYAML), []string{".yaml", ".yml"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeYAML(r)
+	})
+	defaultFormats.register(string(FileFormatTOML), []string{".toml"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeTOML(r)
+	})
+	defaultFormats.register(string(FileFormatHCL), []string{".hcl"}, decodeHCL)
+	defaultFormats.register(string(FileFormatXML), []string{".xml"}, func(r io.Reader, _ string, t reflect.Type, opts ...dotpath.Option) (any, error) {
+		return decodeXML(r, t, opts...)
+	})
+	defaultFormats.register(string(FileFormatCBOR), []string{".cbor"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeCBOR(r)
+	})
+	defaultFormats.register(string(FileFormatMsgPack), []string{".msgpack", ".mpk"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeMsgPack(r)
+	})
+	defaultFormats.register(string(FileFormatINI), []string{".ini"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeINI(r)
+	})
+	defaultFormats.register(string(FileFormatProperties), []string{".properties"}, func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		return decodeProperties(r)
+	})
+}
+
+// Create a new format registry falling back to the given parent (if any).
+func newFormatRegistry(parent *formatRegistry) *formatRegistry {
+	return &formatRegistry{
+		parent:  parent,
+		formats: make(map[string]*format),
+		names:   make([]string, 0),
+	}
+}
+
+// Register a format by its name and file extensions (e.g. ".json").
+// Registering an existing name replaces the format.
+func (r *formatRegistry) register(name string, extensions []string, decode decodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Normalize the extensions (e.g. "JSON" -> ".json").
+	exts := make([]string, 0, len(extensions))
+	for _, ext := range extensions {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		exts = append(exts, ext)
+	}
+
+	r.names = slices.DeleteFunc(r.names, func(n string) bool { return n == name })
+	r.names = append(r.names, name)
+	r.formats[name] = &format{
+		extensions: exts,
+		decode:     decode,
+	}
+}
+
+// Return the format with the given name.
+func (r *formatRegistry) lookup(name string) (*format, bool) {
+	r.mu.RLock()
+	f, ok := r.formats[name]
+	r.mu.RUnlock()
+	if ok {
+		return f, true
+	}
+
+	if r.parent != nil {
+		return r.parent.lookup(name)
+	}
+
+	return nil, false
+}
+
+// Return the name of the format matching the extension of the given path.
+// Formats registered later take precedence.
+func (r *formatRegistry) detect(path string) (string, bool) {
+	path = strings.ToLower(path)
+
+	r.mu.RLock()
+	for _, name := range slices.Backward(r.names) {
+		for _, ext := range r.formats[name].extensions {
+			if strings.HasSuffix(path, ext) {
+				r.mu.RUnlock()
+				return name, true
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	if r.parent != nil {
+		return r.parent.detect(path)
+	}
+
+	return "", false
+}
+
+// Check whether the document is selected by the profile.
+// All documents are selected without discriminator key, otherwise documents without the key
+// and documents with the key set to the profile (or a list containing it).
+// The key is matched according to the configured policy.
+func (p profile) selects(doc any, opts ...dotpath.Option) bool {
+	if p.key == "" {
+		return true
+	}
+
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return true
+	}
+
+	// Find the key (in sorted order for stable results).
+	var v any
+	found := false
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if dotpath.MatchName(k, p.key, opts...) {
+			v, found = m[k], true
+			break
+		}
+	}
+	if !found {
+		return true
+	}
+
+	if values, ok := v.([]any); ok {
+		return slices.ContainsFunc(values, func(v any) bool { return fmt.Sprint(v) == p.value })
+	}
+
+	return fmt.Sprint(v) == p.value
+}
+
+// Adapt the decoder factory to decode documents into generic data.
+func decoderFunc(factory DecoderFactory) decodeFunc {
+	return func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) {
+		var data any
+		err := factory(r).Decode(&data)
+		if err != nil {
+			return nil, err
+		}
+
+		return data, nil
+	}
+}
+
+// Detect the format of a document by its content:
+// valid JSON documents are detected as JSON, otherwise the first line (skipping empty lines and comments) is inspected.
+// Objects and arrays are detected as JSON, tables and key-value pairs ("key = value") as TOML,
+// document markers, directives, sequences and mappings ("key: value") as YAML.
+func sniffFormat(b []byte) (fileFormat, bool) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	if json.Valid(b) {
+		return FileFormatJSON, true
+	}
+
+	for line := range strings.Lines(string(b)) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		switch {
+		case tomlTablePattern.MatchString(line):
+			return FileFormatTOML, true
+		case strings.HasPrefix(line, "{") || strings.HasPrefix(line, "["):
+			return FileFormatJSON, true
+		case tomlKeyPattern.MatchString(line):
+			return FileFormatTOML, true
+		case line == "---" || strings.HasPrefix(line, "--- ") || strings.HasPrefix(line, "%YAML") ||
+			line == "-" || strings.HasPrefix(line, "- ") || yamlKeyPattern.MatchString(line):
+			return FileFormatYAML, true
+		}
+
+		return "", false
+	}
+
+	return "", false
+}
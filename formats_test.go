@@ -0,0 +1,116 @@
+package confless
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codetent/confless/pkg/dotpath"
+)
+
+// Decodes "key=value" lines (used to test custom formats).
+type keyValueDecoder struct {
+	r io.Reader
+}
+
+func (d *keyValueDecoder) Decode(v any) error {
+	data := make(map[string]any)
+
+	s := bufio.NewScanner(d.r)
+	for s.Scan() {
+		key, value, ok := strings.Cut(s.Text(), "=")
+		if ok {
+			data[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	*v.(*any) = data
+	return s.Err()
+}
+
+func newKeyValueDecoder(r io.Reader) Decoder {
+	return &keyValueDecoder{r: r}
+}
+
+func Test_formatRegistry(t *testing.T) {
+	decode := func(r io.Reader, _ string, _ reflect.Type, _ ...dotpath.Option) (any, error) { return nil, nil }
+
+	parent := newFormatRegistry(nil)
+	parent.register("json", []string{".json"}, decode)
+	parent.register("yaml", []string{".yaml", ".yml"}, decode)
+
+	r := newFormatRegistry(parent)
+	r.register("kv", []string{"KV", ".conf"}, decode)
+	r.register("json-gz", []string{".json.gz"}, decode)
+
+	tests := []struct {
+		name   string
+		path   string
+		want   string
+		wantOk bool
+	}{
+		{name: "own extension", path: "app.kv", want: "kv", wantOk: true},
+		{name: "own extension with uppercase", path: "APP.CONF", want: "kv", wantOk: true},
+		{name: "compound extension", path: "app.json.gz", want: "json-gz", wantOk: true},
+		{name: "parent extension", path: "app.yml", want: "yaml", wantOk: true},
+		{name: "unknown extension", path: "app.txt", wantOk: false},
+		{name: "extension without dot", path: "appkv", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := r.detect(tt.path)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("detect() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+
+	if _, ok := r.lookup("json"); !ok {
+		t.Error("lookup() did not fall back to the parent")
+	}
+	if _, ok := parent.lookup("kv"); ok {
+		t.Error("lookup() found a format of the child in the parent")
+	}
+
+	// Formats registered later take precedence.
+	r.register("other", []string{".kv"}, decode)
+	if got, _ := r.detect("app.kv"); got != "other" {
+		t.Errorf("detect() = %q, want %q", got, "other")
+	}
+}
+
+func Test_sniffFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    fileFormat
+		wantOk  bool
+	}{
+		{name: "JSON object", content: `{"name": "MyApp"}`, want: FileFormatJSON, wantOk: true},
+		{name: "JSON array on one line", content: `["a", "b"]`, want: FileFormatJSON, wantOk: true},
+		{name: "invalid JSON object", content: "{\n  name: 'MyApp',\n}", want: FileFormatJSON, wantOk: true},
+		{name: "JSON with byte order mark", content: "\xef\xbb\xbf{}", want: FileFormatJSON, wantOk: true},
+		{name: "TOML table", content: "[database]\nhost = \"localhost\"", want: FileFormatTOML, wantOk: true},
+		{name: "TOML array of tables", content: "[[servers]]\nname = \"a\"", want: FileFormatTOML, wantOk: true},
+		{name: "TOML dotted table with comment", content: "[database.\"primary\"] # main\n", want: FileFormatTOML, wantOk: true},
+		{name: "TOML key after comments", content: "# settings\n\nname = \"MyApp\"", want: FileFormatTOML, wantOk: true},
+		{name: "TOML dotted key", content: "database.host = \"localhost\"", want: FileFormatTOML, wantOk: true},
+		{name: "YAML document marker", content: "---\nname: MyApp", want: FileFormatYAML, wantOk: true},
+		{name: "YAML directive", content: "%YAML 1.2\n---\nname: MyApp", want: FileFormatYAML, wantOk: true},
+		{name: "YAML mapping", content: "name: MyApp\nport: 8080", want: FileFormatYAML, wantOk: true},
+		{name: "YAML mapping with equal sign in value", content: "url: http://localhost?a=b", want: FileFormatYAML, wantOk: true},
+		{name: "YAML sequence", content: "- a\n- b", want: FileFormatYAML, wantOk: true},
+		{name: "plain text", content: "hello world", wantOk: false},
+		{name: "empty", content: "\n# only comments\n", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sniffFormat([]byte(tt.content))
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("sniffFormat() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -3,12 +3,30 @@ module github.com/codetent/confless
 go 1.24.5
 
 require (
+	github.com/fxamacker/cbor/v2 v2.9.2
 	github.com/goccy/go-yaml v1.18.0
+	github.com/hashicorp/hcl/v2 v2.24.0
+	github.com/klauspost/compress v1.18.0
+	github.com/magiconair/properties v1.8.9
+	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/spf13/afero v1.15.0
 	github.com/spf13/cast v1.10.0
+	github.com/tailscale/hujson v0.0.0-20250605163823-992244df8c5a
+	github.com/titanous/json5 v1.0.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	github.com/zclconf/go-cty v1.16.3
+	gopkg.in/ini.v1 v1.67.2
 )
 
 require (
 	dario.cat/mergo v1.0.2
+	github.com/agext/levenshtein v1.2.1 // indirect
+	github.com/apparentlymart/go-textseg/v15 v15.0.0 // indirect
+	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/mod v0.26.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/text v0.28.0 // indirect
+	golang.org/x/tools v0.35.0 // indirect
 )
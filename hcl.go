@@ -0,0 +1,190 @@
+package confless
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/codetent/confless/pkg/dotpath"
+)
+
+// Decodes an HCL document into generic data (maps, slices and basic values) for the given type.
+// The file name is reported in the positions of errors.
+// Blocks are mapped according to the type of the field they are decoded into:
+// blocks are decoded into nested structs, labelled blocks into maps (by label) or slices of structs.
+// Labels of blocks decoded into slices are set to the fields marked as label (e.g. `confless:"label"`).
+func decodeHCL(r io.Reader, filename string, t reflect.Type, opts ...dotpath.Option) (any, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("unsupported body: %T", file.Body)
+	}
+
+	return decodeHCLBody(body, t, opts...)
+}
+
+// Decodes the attributes and blocks of the body into a map.
+func decodeHCLBody(body *hclsyntax.Body, t reflect.Type, opts ...dotpath.Option) (map[string]any, error) {
+	data := make(map[string]any)
+
+	for name, attr := range body.Attributes {
+		// Evaluate the expression without variables and functions.
+		v, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		value, err := ctyToData(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", attr.SrcRange, err)
+		}
+
+		data[name] = value
+	}
+
+	for _, block := range body.Blocks {
+		value, err := decodeHCLBlock(block.Body, block.Labels, findFieldType(t, block.Type, opts...), opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Repeated blocks are merged.
+		data[block.Type] = mergeHCLData(data[block.Type], value)
+	}
+
+	return data, nil
+}
+
+// Decodes the block with the given labels according to the type it is decoded into.
+func decodeHCLBlock(body *hclsyntax.Body, labels []string, t reflect.Type, opts ...dotpath.Option) (any, error) {
+	t = unwrapType(t)
+
+	// Decode the block as an element of slices.
+	if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		elem := unwrapType(t.Elem())
+
+		value, err := decodeHCLBody(body, elem, opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Set the labels to the fields marked as label (in order).
+		if elem.Kind() == reflect.Struct {
+			i := 0
+			for name, field := range dotpath.TypeFields(elem, opts...) {
+				if i < len(labels) && parseTag(field.Tag)["label"] != "" {
+					value[name] = labels[i]
+					i++
+				}
+			}
+		}
+
+		return []any{value}, nil
+	}
+
+	// Decode the labels as keys of nested maps.
+	if len(labels) > 0 {
+		value, err := decodeHCLBlock(body, labels[1:], findFieldType(t, labels[0], opts...), opts...)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]any{labels[0]: value}, nil
+	}
+
+	return decodeHCLBody(body, t, opts...)
+}
+
+// Merges the data of repeated blocks.
+// Slices are concatenated and maps are merged recursively, otherwise the new value wins.
+func mergeHCLData(dst any, src any) any {
+	switch s := src.(type) {
+	case []any:
+		if d, ok := dst.([]any); ok {
+			return append(d, s...)
+		}
+	case map[string]any:
+		if d, ok := dst.(map[string]any); ok {
+			for k, v := range s {
+				d[k] = mergeHCLData(d[k], v)
+			}
+
+			return d
+		}
+	}
+
+	return src
+}
+
+// Converts the value of an expression to generic data.
+func ctyToData(v cty.Value) (any, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+
+	if !v.IsWhollyKnown() {
+		return nil, fmt.Errorf("value is unknown")
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString(), nil
+	case t == cty.Bool:
+		return v.True(), nil
+	case t == cty.Number:
+		f := v.AsBigFloat()
+		if f.IsInt() {
+			if i, acc := f.Int64(); acc == big.Exact {
+				return i, nil
+			}
+		}
+
+		c, _ := f.Float64()
+		return c, nil
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		values := make([]any, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, e := it.Element()
+
+			value, err := ctyToData(e)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return values, nil
+	case t.IsMapType() || t.IsObjectType():
+		values := make(map[string]any, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			k, e := it.Element()
+
+			value, err := ctyToData(e)
+			if err != nil {
+				return nil, err
+			}
+
+			values[k.AsString()] = value
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %s", t.FriendlyName())
+	}
+}
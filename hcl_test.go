@@ -0,0 +1,51 @@
+package confless
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decodeHCL(t *testing.T) {
+	type Config struct {
+		Name string
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		filename string
+		want     any
+		wantErr  string
+	}{
+		{
+			name:     "attributes",
+			content:  "name = \"MyApp\"",
+			filename: "config.hcl",
+			want:     map[string]any{"name": "MyApp"},
+		},
+		{
+			name:     "error reports file name",
+			content:  "name = \"MyApp\"\nport =",
+			filename: "config.hcl",
+			wantErr:  "config.hcl:2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeHCL(strings.NewReader(tt.content), tt.filename, reflect.TypeOf(Config{}))
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeHCL() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatal("decodeHCL() succeeded unexpectedly")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeHCL() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
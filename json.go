@@ -0,0 +1,138 @@
+package confless
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/tailscale/hujson"
+	"github.com/titanous/json5"
+)
+
+// Decodes a JSON document of the given format (json, jsonc or json5) into generic data.
+// Syntax errors report the line and column in the original document.
+func decodeJSON(r io.Reader, format string) (any, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	var data any
+	switch format {
+	case "jsonc":
+		// Replace comments and trailing commas by whitespace (keeps the positions).
+		// A copy is passed, as the bytes are modified in place.
+		std, err := hujson.Standardize(bytes.Clone(b))
+		if err != nil {
+			return nil, err
+		}
+
+		err = decodeStrictJSON(std, &data)
+		if err != nil {
+			return nil, positionError(b, err)
+		}
+	case "json5":
+		dec := json5.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber()
+
+		err := dec.Decode(&data)
+		if err != nil {
+			return nil, positionError(b, err)
+		}
+
+		data, err = json5ToData(data)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		err := decodeStrictJSON(b, &data)
+		if err != nil {
+			return nil, positionError(b, err)
+		}
+	}
+
+	return data, nil
+}
+
+// Decodes the JSON document keeping numbers as json.Number.
+func decodeStrictJSON(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	return dec.Decode(v)
+}
+
+// Converts the numbers of decoded JSON5 data to json.Number (like decoded JSON data).
+// Hexadecimal and signed literals are normalized, so integers keep their precision.
+func json5ToData(data any) (any, error) {
+	switch d := data.(type) {
+	case []any:
+		for i, v := range d {
+			value, err := json5ToData(v)
+			if err != nil {
+				return nil, err
+			}
+
+			d[i] = value
+		}
+	case map[string]any:
+		for k, v := range d {
+			value, err := json5ToData(v)
+			if err != nil {
+				return nil, err
+			}
+
+			d[k] = value
+		}
+	case json5.Number:
+		if i, err := d.Int64(); err == nil {
+			return json.Number(strconv.FormatInt(i, 10)), nil
+		}
+
+		f, err := d.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s: %w", d, err)
+		}
+
+		// Keep the literal if valid in JSON (e.g. integers beyond int64).
+		if json.Valid([]byte(d)) {
+			return json.Number(d), nil
+		}
+
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+
+	return data, nil
+}
+
+// Adds the line and column of the error offset in the given document to syntax errors.
+func positionError(b []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var syntaxErr5 *json5.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &syntaxErr5):
+		offset = syntaxErr5.Offset
+	default:
+		return err
+	}
+
+	line, column := position(b, offset)
+	return fmt.Errorf("line %d, column %d: %w", line, column, err)
+}
+
+// Returns the line and column (starting at 1) of the character read last at the given offset.
+func position(b []byte, offset int64) (int, int) {
+	pos := int(min(max(offset-1, 0), int64(len(b))))
+	before := b[:pos]
+
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := pos - bytes.LastIndexByte(before, '\n')
+
+	return line, column
+}
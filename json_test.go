@@ -0,0 +1,80 @@
+package confless
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_decodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		format  string
+		want    any
+		wantErr string
+	}{
+		{
+			name:   "decode JSON with comments and trailing commas",
+			src:    "{\n  // comment\n  \"name\": \"MyApp\", /* block */\n  \"tags\": [\"a\",],\n}",
+			format: "jsonc",
+			want:   map[string]any{"name": "MyApp", "tags": []any{"a"}},
+		},
+		{
+			name:   "decode JSON5",
+			src:    "{\n  // comment\n  name: 'MyApp',\n  port: 0x1F90,\n}",
+			format: "json5",
+			want:   map[string]any{"name": "MyApp", "port": json.Number("8080")},
+		},
+		{
+			name:   "decode JSON5 numbers without losing precision",
+			src:    "{id: 9007199254740993, hex: -0x20000000000001, big: 18446744073709551615, ratio: .5, signed: +1.5}",
+			format: "json5",
+			want: map[string]any{
+				"id":     json.Number("9007199254740993"),
+				"hex":    json.Number("-9007199254740993"),
+				"big":    json.Number("18446744073709551615"),
+				"ratio":  json.Number("0.5"),
+				"signed": json.Number("1.5"),
+			},
+		},
+		{
+			name:    "report position of JSON syntax error",
+			src:     "{\n  \"name\": \"MyApp\",\n  \"port\": ,\n}",
+			format:  "json",
+			wantErr: "line 3, column 11",
+		},
+		{
+			name:    "report position of JSONC syntax error after comments",
+			src:     "{\n  /* a comment\n  spanning lines */\n  \"name\": \"MyApp\"\n  \"port\": 8080\n}",
+			format:  "jsonc",
+			wantErr: "line 5, column 3",
+		},
+		{
+			name:    "report position of JSON5 syntax error",
+			src:     "{\n  name: 'MyApp',\n  port: ]\n}",
+			format:  "json5",
+			wantErr: "line 3, column 9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotErr := decodeJSON(strings.NewReader(tt.src), tt.format)
+			if gotErr != nil {
+				if tt.wantErr == "" {
+					t.Errorf("decodeJSON() failed: %v", gotErr)
+				} else if !strings.Contains(gotErr.Error(), tt.wantErr) {
+					t.Errorf("decodeJSON() error = %v, want %s", gotErr, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatal("decodeJSON() succeeded unexpectedly")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,49 +1,128 @@
 package confless
 
 import (
+	"encoding/base64"
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/spf13/afero"
+
+	"github.com/codetent/confless/pkg/dotpath"
+	"github.com/codetent/confless/pkg/naming"
 )
 
+// Environment variable set by systemd to the directory of the credentials.
+const credentialsDirEnv = "CREDENTIALS_DIRECTORY"
+
 type configFile struct {
 	path   string
 	format fileFormat
 }
 
+type envSource struct {
+	prefix     string
+	nesting    envNesting
+	naming     naming.Strategy
+	unprefixed bool
+	files      bool
+	scrubbing  envScrubbing
+}
+
+type dirSource struct {
+	path      string
+	pathEnv   string
+	separator string
+}
+
+type docSource struct {
+	name       string
+	format     fileFormat
+	base64     bool
+	precedence precedence
+}
+
+type dotEnvSource struct {
+	path    string
+	cascade bool
+	profile string
+}
+
+// Returns the paths of the files to load (in order of precedence).
+func (d *dotEnvSource) paths() []string {
+	if !d.cascade {
+		return []string{d.path}
+	}
+
+	paths := []string{d.path, d.path + ".local"}
+	if d.profile != "" {
+		paths = append(paths, d.path+"."+d.profile)
+	}
+
+	return paths
+}
+
+type flagSource struct {
+	set    *flag.FlagSet
+	naming naming.Strategy
+}
+
 type loader struct {
-	fs        afero.Fs
-	envReader func() []string
+	fs           afero.Fs
+	envReader    func() []string
+	envUnsetter  func(name string) error
+	pathOpts     []dotpath.Option
+	ambiguity    func(err error) error
+	scrubHandler func(names []string)
+	formats      *formatRegistry
+	profile      profile
 
-	envPrefix string
-	flagSets  []*flag.FlagSet
-	files     []*configFile
+	envs    []*envSource
+	flags   []*flagSource
+	files   []*configFile
+	dirs    []*dirSource
+	docs    []*docSource
+	dotenvs []*dotEnvSource
 }
 
 // Detect the file format based on the extension.
-func detectFileFormat(path string) fileFormat {
-	ext := filepath.Ext(path)
-	switch strings.ToLower(ext) {
-	case ".json":
-		return FileFormatJSON
-	case ".yaml", ".yml":
-		return FileFormatYAML
-	default:
-		return FileFormatJSON
+// Falls back to detection by the content for unknown extensions.
+func (l *loader) detectFileFormat(path string) fileFormat {
+	name, ok := l.formats.detect(path)
+	if !ok {
+		return FileFormatAuto
 	}
+
+	return fileFormat(name)
+}
+
+// Returns the value of the environment variable with the given name.
+func lookupEnv(envs []string, name string) (string, bool) {
+	for _, env := range envs {
+		key, value, ok := strings.Cut(env, "=")
+		if ok && key == name {
+			return value, true
+		}
+	}
+
+	return "", false
 }
 
 // Creates a new loader with the given options.
 func NewLoader(opts ...loaderOption) *loader {
 	l := &loader{
-		fs:        afero.NewOsFs(),
-		envReader: os.Environ,
-		flagSets:  make([]*flag.FlagSet, 0),
-		files:     make([]*configFile, 0),
+		fs:          afero.NewOsFs(),
+		envReader:   os.Environ,
+		envUnsetter: os.Unsetenv,
+		envs:        make([]*envSource, 0),
+		flags:       make([]*flagSource, 0),
+		files:       make([]*configFile, 0),
+		dirs:        make([]*dirSource, 0),
+		docs:        make([]*docSource, 0),
+		dotenvs:     make([]*dotEnvSource, 0),
+		formats:     newFormatRegistry(defaultFormats),
 	}
 
 	// Apply the given options.
@@ -56,15 +135,25 @@ func NewLoader(opts ...loaderOption) *loader {
 
 // Register an environment variable prefix to load.
 // Names are converted to dot-separated paths (e.g. "MY_FLAG" -> "my.flag").
-func (l *loader) RegisterEnv(pre string) {
-	l.envPrefix = pre
+// Multiple prefixes can be registered, later ones take precedence.
+func (l *loader) RegisterEnv(pre string, opts ...envOption) {
+	env := &envSource{
+		prefix:  pre,
+		nesting: EnvNestingUnderscore,
+	}
+
+	// Apply the given options.
+	for _, opt := range opts {
+		opt(env)
+	}
+
+	l.envs = append(l.envs, env)
 }
 
 // Register a file to load.
 func (l *loader) RegisterFile(path string, opts ...fileOption) {
 	file := &configFile{
-		path:   path,
-		format: detectFileFormat(path),
+		path: path,
 	}
 
 	// Apply the given options.
@@ -75,82 +164,327 @@ func (l *loader) RegisterFile(path string, opts ...fileOption) {
 	l.files = append(l.files, file)
 }
 
+// Register a directory to load with one file per key (e.g. Kubernetes secret volumes).
+// File names are converted to dot-separated paths by the separator (e.g. "database.host").
+func (l *loader) RegisterDir(path string, opts ...dirOption) {
+	dir := &dirSource{
+		path:      path,
+		separator: ".",
+	}
+
+	// Apply the given options.
+	for _, opt := range opts {
+		opt(dir)
+	}
+
+	l.dirs = append(l.dirs, dir)
+}
+
+// Register the systemd credentials directory to load (see LoadCredential= of systemd.exec).
+// The directory is located by the $CREDENTIALS_DIRECTORY environment variable, credentials are skipped if unset.
+// Credential names are converted to dot-separated paths by the separator (e.g. "database.password").
+func (l *loader) RegisterCredentials(opts ...dirOption) {
+	l.RegisterDir("", append([]dirOption{withDirPathEnv(credentialsDirEnv)}, opts...)...)
+}
+
+// Register an environment variable containing a whole configuration document to load (e.g. "APP_CONFIG").
+// The format is detected from the content unless set explicitly, documents are loaded after the files by default.
+func (l *loader) RegisterEnvDocument(name string, opts ...docOption) {
+	doc := &docSource{
+		name:       name,
+		precedence: PrecedenceAfterFiles,
+	}
+
+	// Apply the given options.
+	for _, opt := range opts {
+		opt(doc)
+	}
+
+	l.docs = append(l.docs, doc)
+}
+
+// Register a dotenv file to load (e.g. ".env").
+// Its variables are mapped by the registered environment variable prefixes, the environment takes precedence.
+// The process environment is not modified.
+func (l *loader) RegisterDotEnv(path string, opts ...dotEnvOption) {
+	dotenv := &dotEnvSource{
+		path: path,
+	}
+
+	// Apply the given options.
+	for _, opt := range opts {
+		opt(dotenv)
+	}
+
+	l.dotenvs = append(l.dotenvs, dotenv)
+}
+
 // Register the flags to load.
 // Names are converted to dot-separated paths (e.g. "my-flag" -> "my.flag").
 // Note that flags must be parsed before loading.
-func (l *loader) RegisterFlags(f *flag.FlagSet) {
-	l.flagSets = append(l.flagSets, f)
+func (l *loader) RegisterFlags(f *flag.FlagSet, opts ...flagOption) {
+	flags := &flagSource{
+		set: f,
+	}
+
+	// Apply the given options.
+	for _, opt := range opts {
+		opt(flags)
+	}
+
+	l.flags = append(l.flags, flags)
+}
+
+// Register a file format by its name and file extensions (e.g. "cue" with ".cue").
+// The format can be selected by WithFileFormat or the format tag option, or is detected by the extensions.
+// Formats registered on the loader take precedence over globally registered and built-in formats.
+func (l *loader) RegisterFormat(name string, extensions []string, factory DecoderFactory) {
+	l.formats.register(name, extensions, decoderFunc(factory))
 }
 
 // Populate the object by applying the registered sources.
 func (l *loader) Load(obj any) error {
+	// Check for ambiguous names before setting any value (skipped without a handler).
+	if l.ambiguity != nil {
+		err := dotpath.Check(obj, l.pathOpts...)
+		if err != nil {
+			err = l.ambiguity(err)
+			if err != nil {
+				return fmt.Errorf("failed to check object: %w", err)
+			}
+		}
+	}
+
+	err := l.loadDocuments(obj, PrecedenceBeforeFiles)
+	if err != nil {
+		return err
+	}
+
 	// Load the files.
 	for _, file := range l.files {
-		// Open the file.
-		f, err := l.fs.Open(file.path)
+		err = l.loadFile(obj, file.path, file.format)
 		if err != nil {
-			if os.IsNotExist(err) {
-				// Skip if file does not exist.
+			return err
+		}
+	}
+
+	err = l.loadDocuments(obj, PrecedenceAfterFiles)
+	if err != nil {
+		return err
+	}
+
+	// Load the directories.
+	for _, dir := range l.dirs {
+		// Locate the directory by the environment variable if set.
+		if dir.pathEnv != "" {
+			path, ok := lookupEnv(l.envReader(), dir.pathEnv)
+			if !ok || path == "" {
 				continue
 			}
 
-			return fmt.Errorf("failed to open file: %w", err)
+			dir = &dirSource{path: path, separator: dir.separator}
 		}
 
-		// Populate the object by the file.
-		err = populateByFile(f, string(file.format), obj)
-		_ = f.Close()
+		err := populateByDir(l.fs, dir, obj, l.pathOpts...)
 		if err != nil {
-			return fmt.Errorf("failed to load file: %w", err)
+			return fmt.Errorf("failed to load directory: %w", err)
 		}
 	}
 
 	// Load the flags.
-	for _, fset := range l.flagSets {
-		err := populateByFlags(fset, obj)
+	for _, flags := range l.flags {
+		err := populateByFlags(flags, obj, l.pathOpts...)
 		if err != nil {
 			return fmt.Errorf("failed to load flags: %w", err)
 		}
 	}
 
+	err = l.loadDocuments(obj, PrecedenceAfterFlags)
+	if err != nil {
+		return err
+	}
+
+	// Load the dotenv files.
+	dotenv, err := l.loadDotEnv()
+	if err != nil {
+		return err
+	}
+
 	// Load the environment variables.
-	if l.envPrefix != "" {
-		err := populateByEnv(l.envReader(), l.envPrefix, obj)
+	scrubbed := make([]string, 0)
+	for _, env := range l.envs {
+		names, err := populateByEnv(l.fs, l.variables(dotenv), env, obj, l.pathOpts...)
 		if err != nil {
 			return fmt.Errorf("failed to load env: %w", err)
 		}
+
+		// Scrub the consumed variables from the environment.
+		for _, name := range names {
+			// Skip variables already scrubbed or not set in the environment (e.g. from dotenv files).
+			if slices.Contains(scrubbed, name) {
+				continue
+			}
+			if _, ok := lookupEnv(l.envReader(), name); !ok {
+				continue
+			}
+
+			err := l.envUnsetter(name)
+			if err != nil {
+				return fmt.Errorf("failed to scrub env %s: %w", name, err)
+			}
+
+			scrubbed = append(scrubbed, name)
+		}
+	}
+
+	// Report the scrubbed variables.
+	if len(scrubbed) > 0 && l.scrubHandler != nil {
+		l.scrubHandler(scrubbed)
+	}
+
+	err = l.loadDocuments(obj, PrecedenceAfterEnv)
+	if err != nil {
+		return err
 	}
 
 	// Load dynamically files.
-	for field, format := range findFileFields(obj) {
+	for field, format := range findFileFields(obj, l.pathOpts...) {
 		path := field.String()
 		if path == "" {
 			continue
 		}
 
-		format := fileFormat(format)
-		if format == "" {
-			format = detectFileFormat(path)
+		err = l.loadFile(obj, path, fileFormat(format))
+		if err != nil {
+			return err
 		}
+	}
 
-		// Open the file.
-		f, err := l.fs.Open(path)
-		if err != nil {
-			if os.IsNotExist(err) {
-				// Skip if file does not exist.
-				continue
+	return nil
+}
+
+// Returns the environment variables to map to fields.
+// The variables of the environment take precedence over the given ones (e.g. from dotenv files).
+// Variables containing documents are excluded, as they are loaded as a whole.
+func (l *loader) variables(extra []string) []string {
+	return slices.DeleteFunc(append(slices.Clone(extra), l.envReader()...), func(env string) bool {
+		key, _, _ := strings.Cut(env, "=")
+		return slices.ContainsFunc(l.docs, func(doc *docSource) bool {
+			return strings.EqualFold(doc.name, key)
+		})
+	})
+}
+
+// Populate the object by the file with the given path (skipped if missing).
+// Compressed files are decompressed, the format is detected by the remaining extension if not set.
+func (l *loader) loadFile(obj any, path string, format fileFormat) error {
+	// Open the file.
+	f, err := l.fs.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Skip if file does not exist.
+			return nil
+		}
+
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	r, path, err := decompress(f, path)
+	if err != nil {
+		return fmt.Errorf("failed to load file: %w", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	if format == "" {
+		format = l.detectFileFormat(path)
+	}
+
+	// Populate the object by the file.
+	err = populateByFile(l.formats, l.profile, r, path, string(format), obj, l.pathOpts...)
+	if err != nil {
+		return fmt.Errorf("failed to load file: %w", err)
+	}
+
+	return nil
+}
+
+// Populate the object by the documents of environment variables with the given precedence.
+func (l *loader) loadDocuments(obj any, p precedence) error {
+	for _, doc := range l.docs {
+		if doc.precedence != p {
+			continue
+		}
+
+		// Skip if the variable is not set.
+		content, ok := lookupEnv(l.envReader(), doc.name)
+		if !ok || content == "" {
+			continue
+		}
+
+		// Decode the content if encoded.
+		if doc.base64 {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(content))
+			if err != nil {
+				return fmt.Errorf("failed to decode env %s: invalid base64", doc.name)
 			}
 
-			return fmt.Errorf("failed to open file: %w", err)
+			content = string(decoded)
 		}
 
-		// Populate the object by the file.
-		err = populateByFile(f, string(format), obj)
-		_ = f.Close()
+		// Detect the format by the content unless set.
+		format := doc.format
+		if format == "" {
+			format = FileFormatAuto
+		}
+
+		// Populate the object by the document.
+		err := populateByFile(l.formats, l.profile, strings.NewReader(content), doc.name, string(format), obj, l.pathOpts...)
 		if err != nil {
-			return fmt.Errorf("failed to load file: %w", err)
+			return fmt.Errorf("failed to load env %s: %w", doc.name, err)
 		}
 	}
 
 	return nil
 }
+
+// Returns the variables of the registered dotenv files (in order of precedence).
+// Missing files are skipped.
+func (l *loader) loadDotEnv() ([]string, error) {
+	entries := make([]string, 0)
+
+	// Look up references by the previous files and the environment.
+	lookup := func(name string) (string, bool) {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if key, value, _ := strings.Cut(entries[i], "="); key == name {
+				return value, true
+			}
+		}
+
+		return lookupEnv(l.envReader(), name)
+	}
+
+	for _, dotenv := range l.dotenvs {
+		for _, path := range dotenv.paths() {
+			f, err := l.fs.Open(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					// Skip if file does not exist.
+					continue
+				}
+
+				return nil, fmt.Errorf("failed to open dotenv file: %w", err)
+			}
+
+			parsed, err := parseDotEnv(f, lookup)
+			_ = f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to load dotenv file %s: %w", path, err)
+			}
+
+			entries = append(entries, parsed...)
+		}
+	}
+
+	return entries, nil
+}
@@ -1,11 +1,17 @@
 package confless
 
 import (
+	"encoding/base64"
+	"errors"
 	"flag"
 	"reflect"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/spf13/afero"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/codetent/confless/pkg/naming"
 )
 
 func Test_loader_RegisterEnv(t *testing.T) {
@@ -91,6 +97,435 @@ func Test_loader_RegisterEnv(t *testing.T) {
 	}
 }
 
+func Test_loader_RegisterEnvMultiple(t *testing.T) {
+	type Config struct {
+		Name string
+		Host string
+		Port int `confless:"env=PORT"`
+	}
+
+	tests := []struct {
+		name    string
+		env     []string
+		srcs    func(l *loader)
+		wantErr bool
+		want    Config
+	}{
+		{
+			name: "later prefixes take precedence",
+			env:  []string{"COMMON_NAME=common", "COMMON_HOST=localhost", "MYAPP_NAME=myapp"},
+			srcs: func(l *loader) {
+				l.RegisterEnv("COMMON")
+				l.RegisterEnv("MYAPP")
+			},
+			want: Config{Name: "myapp", Host: "localhost"},
+		},
+		{
+			name: "unprefixed env populates only declared names",
+			env:  []string{"PORT=8080", "NAME=ignored"},
+			srcs: func(l *loader) {
+				l.RegisterEnv("", WithUnprefixedEnv())
+			},
+			want: Config{Port: 8080},
+		},
+		{
+			name: "prefixed env overrides unprefixed env",
+			env:  []string{"PORT=8080", "MYAPP_PORT=9090"},
+			srcs: func(l *loader) {
+				l.RegisterEnv("", WithUnprefixedEnv())
+				l.RegisterEnv("MYAPP")
+			},
+			want: Config{Port: 9090},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &Config{}
+			l := NewLoader(WithEnvReader(func() []string { return tt.env }))
+			tt.srcs(l)
+			err := l.Load(obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && *obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *obj, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loader_RegisterDir(t *testing.T) {
+	type Config struct {
+		Name string
+		Host string
+		Port int
+	}
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "config.json", []byte(`{"name": "file", "host": "file", "port": 1}`), 0644)
+	_ = afero.WriteFile(fs, "/run/secrets/name", []byte("dir\n"), 0644)
+	_ = afero.WriteFile(fs, "/run/secrets/host", []byte("dir\n"), 0644)
+
+	l := NewLoader(
+		WithFS(fs),
+		WithEnvReader(func() []string { return []string{"APP_HOST=env"} }),
+	)
+	l.RegisterFile("config.json")
+	l.RegisterDir("/run/secrets")
+	l.RegisterEnv("APP")
+
+	obj := &Config{}
+	err := l.Load(obj)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	want := Config{Name: "dir", Host: "env", Port: 1}
+	if *obj != want {
+		t.Errorf("Load() = %+v, want %+v", *obj, want)
+	}
+}
+
+func Test_loader_RegisterCredentials(t *testing.T) {
+	type Database struct {
+		User     string
+		Password string
+	}
+
+	type Config struct {
+		Database Database
+	}
+
+	tests := []struct {
+		name    string
+		env     []string
+		files   map[string]string
+		opts    []dirOption
+		wantErr bool
+		want    Database
+	}{
+		{
+			name: "load credentials from credentials directory",
+			env:  []string{"CREDENTIALS_DIRECTORY=/run/credentials/app.service"},
+			files: map[string]string{
+				"database.password": "s3cret\n",
+				"database_user":     "admin\n",
+			},
+			want: Database{Password: "s3cret"},
+		},
+		{
+			name: "load credentials with custom separator",
+			env:  []string{"CREDENTIALS_DIRECTORY=/run/credentials/app.service"},
+			files: map[string]string{
+				"database_password": "s3cret\n",
+				"database.user":     "admin\n",
+			},
+			opts: []dirOption{WithDirSeparator("_")},
+			want: Database{User: "admin", Password: "s3cret"},
+		},
+		{
+			name: "overlapping credential names are applied in sorted order",
+			env:  []string{"CREDENTIALS_DIRECTORY=/run/credentials/app.service"},
+			files: map[string]string{
+				"database.password": "first\n",
+				"database_password": "last\n",
+			},
+			opts: []dirOption{WithDirSeparator("_")},
+			want: Database{Password: "last"},
+		},
+		{
+			name: "skip credentials if directory is not set",
+			env:  []string{},
+			files: map[string]string{
+				"database.password": "s3cret\n",
+			},
+			want: Database{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, content := range tt.files {
+				_ = afero.WriteFile(fs, "/run/credentials/app.service/"+name, []byte(content), 0400)
+			}
+
+			l := NewLoader(WithFS(fs), WithEnvReader(func() []string { return tt.env }))
+			l.RegisterCredentials(tt.opts...)
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if obj.Database != tt.want {
+				t.Errorf("expected Database to be %+v, got %+v", tt.want, obj.Database)
+			}
+		})
+	}
+}
+
+func Test_loader_WithEnvScrubbing(t *testing.T) {
+	type Config struct {
+		Name     string
+		Password string `confless:"secret"`
+		Token    string `confless:"env=API_TOKEN,secret"`
+	}
+
+	tests := []struct {
+		name      string
+		env       []string
+		scrubbing envScrubbing
+		want      []string
+	}{
+		{
+			name:      "keep variables by default",
+			env:       []string{"APP_NAME=MyApp", "APP_PASSWORD=s3cret"},
+			scrubbing: EnvScrubNone,
+			want:      nil,
+		},
+		{
+			name:      "scrub variables of secret fields",
+			env:       []string{"APP_NAME=MyApp", "APP_PASSWORD=s3cret", "API_TOKEN=t0ken", "OTHER=value"},
+			scrubbing: EnvScrubSecrets,
+			want:      []string{"APP_PASSWORD", "API_TOKEN"},
+		},
+		{
+			name:      "scrub all consumed variables",
+			env:       []string{"APP_NAME=MyApp", "APP_PASSWORD=s3cret", "OTHER=value"},
+			scrubbing: EnvScrubConsumed,
+			want:      []string{"APP_NAME", "APP_PASSWORD"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unset, reported []string
+			l := NewLoader(
+				WithEnvReader(func() []string { return tt.env }),
+				WithEnvUnsetter(func(name string) error {
+					unset = append(unset, name)
+					return nil
+				}),
+				WithScrubHandler(func(names []string) {
+					reported = names
+				}),
+			)
+			l.RegisterEnv("APP", WithEnvScrubbing(tt.scrubbing))
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if obj.Name != "MyApp" || obj.Password != "s3cret" {
+				t.Errorf("expected fields to be loaded, got %+v", *obj)
+			}
+			if !reflect.DeepEqual(unset, tt.want) {
+				t.Errorf("expected %v to be unset, got %v", tt.want, unset)
+			}
+			if !reflect.DeepEqual(reported, tt.want) {
+				t.Errorf("expected %v to be reported, got %v", tt.want, reported)
+			}
+		})
+	}
+}
+
+func Test_loader_WithEnvScrubbing_Error(t *testing.T) {
+	l := NewLoader(
+		WithEnvReader(func() []string { return []string{"APP_NAME=MyApp"} }),
+		WithEnvUnsetter(func(name string) error {
+			return errors.New("unset failed")
+		}),
+	)
+	l.RegisterEnv("APP", WithEnvScrubbing(EnvScrubConsumed))
+
+	err := l.Load(&struct{ Name string }{})
+	if err == nil {
+		t.Fatal("Load() succeeded unexpectedly")
+	}
+}
+
+func Test_loader_RegisterEnvDocument(t *testing.T) {
+	type Config struct {
+		Name     string
+		Port     int
+		Database struct {
+			Host string
+		}
+	}
+
+	tests := []struct {
+		name    string
+		env     []string
+		opts    []docOption
+		wantErr bool
+		want    Config
+	}{
+		{
+			name: "load JSON document",
+			env:  []string{`APP_CONFIG={"name": "MyApp", "database": {"host": "localhost"}}`},
+			want: Config{Name: "MyApp", Port: 1, Database: struct{ Host string }{Host: "localhost"}},
+		},
+		{
+			name: "load YAML document",
+			env:  []string{"APP_CONFIG=name: MyApp\ndatabase:\n  host: localhost"},
+			want: Config{Name: "MyApp", Port: 1, Database: struct{ Host string }{Host: "localhost"}},
+		},
+		{
+			name: "load TOML document",
+			env:  []string{"APP_CONFIG=name = \"MyApp\"\n[database]\nhost = \"localhost\""},
+			want: Config{Name: "MyApp", Port: 1, Database: struct{ Host string }{Host: "localhost"}},
+		},
+		{
+			name: "load base64-encoded document",
+			env:  []string{"APP_CONFIG=" + base64.StdEncoding.EncodeToString([]byte(`{"name": "MyApp"}`))},
+			opts: []docOption{WithDocumentBase64()},
+			want: Config{Name: "MyApp", Port: 1},
+		},
+		{
+			name: "load document with explicit format",
+			env:  []string{"APP_CONFIG={name: MyApp}"},
+			opts: []docOption{WithDocumentFormat(FileFormatYAML)},
+			want: Config{Name: "MyApp", Port: 1},
+		},
+		{
+			name: "document is overridden by env by default",
+			env:  []string{`APP_CONFIG={"port": 2}`, "APP_PORT=3"},
+			want: Config{Port: 3},
+		},
+		{
+			name: "document overrides env with highest precedence",
+			env:  []string{`APP_CONFIG={"port": 2}`, "APP_PORT=3"},
+			opts: []docOption{WithDocumentPrecedence(PrecedenceAfterEnv)},
+			want: Config{Port: 2},
+		},
+		{
+			name: "document is overridden by files with lowest precedence",
+			env:  []string{`APP_CONFIG={"name": "MyApp", "port": 2}`},
+			opts: []docOption{WithDocumentPrecedence(PrecedenceBeforeFiles)},
+			want: Config{Name: "MyApp", Port: 1},
+		},
+		{
+			name: "skip unset variable",
+			env:  []string{},
+			want: Config{Port: 1},
+		},
+		{
+			name:    "error for invalid base64",
+			env:     []string{"APP_CONFIG=not base64"},
+			opts:    []docOption{WithDocumentBase64()},
+			wantErr: true,
+		},
+		{
+			name:    "error for document of unknown format",
+			env:     []string{"APP_CONFIG=MyApp"},
+			wantErr: true,
+		},
+		{
+			name:    "error for invalid document",
+			env:     []string{`APP_CONFIG={"name": `},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "config.json", []byte(`{"port": 1}`), 0644)
+
+			l := NewLoader(WithFS(fs), WithEnvReader(func() []string { return tt.env }))
+			l.RegisterFile("config.json")
+			l.RegisterEnv("APP")
+			l.RegisterEnvDocument("APP_CONFIG", tt.opts...)
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && *obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *obj, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loader_RegisterDotEnv(t *testing.T) {
+	type Config struct {
+		Name string
+		Host string
+		Port int
+		URL  string `confless:"env=DATABASE_URL"`
+	}
+
+	tests := []struct {
+		name    string
+		env     []string
+		opts    []dotEnvOption
+		wantErr bool
+		want    Config
+	}{
+		{
+			name: "load variables of dotenv file",
+			want: Config{Name: "base", Host: "base", Port: 1, URL: "postgres://base"},
+		},
+		{
+			name: "environment takes precedence",
+			env:  []string{"APP_NAME=env"},
+			want: Config{Name: "env", Host: "base", Port: 1, URL: "postgres://base"},
+		},
+		{
+			name: "load local file in cascade",
+			opts: []dotEnvOption{WithDotEnvCascade("")},
+			want: Config{Name: "local", Host: "base", Port: 1, URL: "postgres://local"},
+		},
+		{
+			name: "load profile file in cascade",
+			opts: []dotEnvOption{WithDotEnvCascade("production")},
+			want: Config{Name: "local", Host: "production", Port: 1, URL: "postgres://production"},
+		},
+		{
+			name: "skip missing profile file in cascade",
+			opts: []dotEnvOption{WithDotEnvCascade("staging")},
+			want: Config{Name: "local", Host: "base", Port: 1, URL: "postgres://local"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, ".env", []byte("APP_NAME=base\nAPP_HOST=base\nexport APP_PORT=1\nDATABASE_URL=postgres://${APP_HOST}\n"), 0644)
+			_ = afero.WriteFile(fs, ".env.local", []byte("APP_NAME=local\nDATABASE_URL=\"postgres://local\"\n"), 0644)
+			_ = afero.WriteFile(fs, ".env.production", []byte("APP_HOST=production\nDATABASE_URL=postgres://$APP_HOST\n"), 0644)
+
+			var unset []string
+			l := NewLoader(
+				WithFS(fs),
+				WithEnvReader(func() []string { return tt.env }),
+				WithEnvUnsetter(func(name string) error {
+					unset = append(unset, name)
+					return nil
+				}),
+			)
+			l.RegisterDotEnv(".env", tt.opts...)
+			l.RegisterEnv("APP", WithUnprefixedEnv(), WithEnvScrubbing(EnvScrubConsumed))
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && *obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *obj, tt.want)
+			}
+			if len(unset) != len(tt.env) {
+				t.Errorf("expected only variables of the environment to be unset, got %v", unset)
+			}
+		})
+	}
+}
+
 func Test_loader_RegisterFile(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -198,6 +633,180 @@ func Test_loader_RegisterFile(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "load from TOML file with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.toml", []byte("name = \"MyApp\"\nport = 8080"), 0644)
+					return fs
+				}()),
+			},
+			path:     "config.toml",
+			fileOpts: []fileOption{},
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name: "load from HCL file with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.hcl", []byte("name = \"MyApp\"\nport = 8080"), 0644)
+					return fs
+				}()),
+			},
+			path:     "config.hcl",
+			fileOpts: []fileOption{},
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name: "load from INI file with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.ini", []byte("name = MyApp\nport = 8080"), 0644)
+					return fs
+				}()),
+			},
+			path:     "config.ini",
+			fileOpts: []fileOption{},
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name: "load from properties file with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.properties", []byte("name=MyApp\nport=8080"), 0644)
+					return fs
+				}()),
+			},
+			path:     "config.properties",
+			fileOpts: []fileOption{},
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name: "load from JSONC file with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.jsonc", []byte("{\n  // comment\n  \"name\": \"MyApp\",\n  \"port\": 8080,\n}"), 0644)
+					return fs
+				}()),
+			},
+			path:     "config.jsonc",
+			fileOpts: []fileOption{},
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name: "load from JSON5 file with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.json5", []byte("{name: 'MyApp', port: 8080}"), 0644)
+					return fs
+				}()),
+			},
+			path:     "config.json5",
+			fileOpts: []fileOption{},
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
 		{
 			name: "override format with WithFileFormat option",
 			opts: []loaderOption{
@@ -278,7 +887,7 @@ func Test_loader_RegisterFile(t *testing.T) {
 			},
 		},
 		{
-			name: "file without extension defaults to JSON",
+			name: "file without extension detected as JSON by content",
 			opts: []loaderOption{
 				WithFS(func() afero.Fs {
 					fs := afero.NewMemMapFs()
@@ -309,20 +918,320 @@ func Test_loader_RegisterFile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			l := NewLoader(tt.opts...)
+			l := NewLoader(tt.opts...)
+			l.RegisterFile(tt.path, tt.fileOpts...)
+			err := l.Load(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.verify != nil {
+				tt.verify(t, tt.obj)
+			}
+		})
+	}
+}
+
+func Test_loader_RegisterFile_DetectByContent(t *testing.T) {
+	type Config struct {
+		Name string
+		Port int
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		wantErr error
+		want    Config
+	}{
+		{
+			name:    "YAML without extension",
+			path:    "config",
+			content: "---\nname: MyApp\nport: 8080",
+			want:    Config{Name: "MyApp", Port: 8080},
+		},
+		{
+			name:    "TOML with unknown extension",
+			path:    "settings.conf",
+			content: "# settings\nname = \"MyApp\"\nport = 8080",
+			want:    Config{Name: "MyApp", Port: 8080},
+		},
+		{
+			name:    "JSON with unknown extension",
+			path:    "settings.conf",
+			content: `{"name": "MyApp", "port": 8080}`,
+			want:    Config{Name: "MyApp", Port: 8080},
+		},
+		{
+			name:    "undetectable content",
+			path:    "settings.conf",
+			content: "name MyApp",
+			wantErr: ErrUnknownFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, tt.path, []byte(tt.content), 0644)
+
+			l := NewLoader(WithFS(fs))
+			l.RegisterFile(tt.path)
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if *obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *obj, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loader_RegisterFile_MultiDocument(t *testing.T) {
+	type Config struct {
+		Name  string
+		Host  string
+		Port  int
+		Debug bool
+	}
+
+	content := `name: MyApp
+host: localhost
+port: 8080
+---
+profile: dev
+debug: true
+---
+profile: [staging, prod]
+host: db.internal
+---
+# production overrides follow
+---
+profile: prod
+port: 443
+`
+
+	tests := []struct {
+		name string
+		opts []loaderOption
+		want Config
+	}{
+		{
+			name: "documents are applied as layers",
+			want: Config{Name: "MyApp", Host: "db.internal", Port: 443, Debug: true},
+		},
+		{
+			name: "documents are selected by profile",
+			opts: []loaderOption{WithProfile("profile", "prod")},
+			want: Config{Name: "MyApp", Host: "db.internal", Port: 443},
+		},
+		{
+			name: "documents are selected by profile key differing in case",
+			opts: []loaderOption{WithProfile("Profile", "prod")},
+			want: Config{Name: "MyApp", Host: "db.internal", Port: 443},
+		},
+		{
+			name: "documents are selected by profile in list",
+			opts: []loaderOption{WithProfile("profile", "staging")},
+			want: Config{Name: "MyApp", Host: "db.internal", Port: 8080},
+		},
+		{
+			name: "only documents without key are selected by empty profile",
+			opts: []loaderOption{WithProfile("profile", "")},
+			want: Config{Name: "MyApp", Host: "localhost", Port: 8080},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "config.yaml", []byte(content), 0644)
+
+			l := NewLoader(append([]loaderOption{WithFS(fs)}, tt.opts...)...)
+			l.RegisterFile("config.yaml")
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if *obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *obj, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loader_RegisterFile_Binary(t *testing.T) {
+	type Config struct {
+		Name     string `json:"name"`
+		MaxConns int    `json:"max_conns"`
+	}
+
+	data := map[string]any{"name": "MyApp", "max_conns": 10}
+	cborData, _ := cbor.Marshal(data)
+	msgpackData, _ := msgpack.Marshal(data)
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "config.json", []byte(`{"name": "MyApp", "max_conns": 10}`), 0644)
+	_ = afero.WriteFile(fs, "config.cbor", cborData, 0644)
+	_ = afero.WriteFile(fs, "config.msgpack", msgpackData, 0644)
+	_ = afero.WriteFile(fs, "config.bin", cborData, 0644)
+
+	// The same struct is loaded from human-readable and binary files.
+	tests := []struct {
+		path     string
+		fileOpts []fileOption
+	}{
+		{path: "config.json"},
+		{path: "config.cbor"},
+		{path: "config.msgpack"},
+		{path: "config.bin", fileOpts: []fileOption{WithFileFormat(FileFormatCBOR)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			l := NewLoader(WithFS(fs))
+			l.RegisterFile(tt.path, tt.fileOpts...)
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+
+			want := Config{Name: "MyApp", MaxConns: 10}
+			if *obj != want {
+				t.Errorf("Load() = %+v, want %+v", *obj, want)
+			}
+		})
+	}
+}
+
+func Test_loader_RegisterFile_Compressed(t *testing.T) {
+	type Config struct {
+		ConfigFile string `confless:"file"`
+		Name       string
+		Port       int
+		Routes     []string
+	}
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "config.json.gz", gzipContent(`{"name": "MyApp", "port": 8080}`), 0644)
+	_ = afero.WriteFile(fs, "routes.yaml.zst", zstdContent("routes:\n  - /a\n  - /b\n"), 0644)
+	_ = afero.WriteFile(fs, "override", gzipContent("port = 9000"), 0644)
+
+	l := NewLoader(WithFS(fs))
+	l.RegisterFile("config.json.gz")
+	l.RegisterFile("routes.yaml.zst")
+
+	// Dynamic files are decompressed by the magic bytes (format detected by content).
+	obj := &Config{ConfigFile: "override"}
+	err := l.Load(obj)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	if obj.Name != "MyApp" || obj.Port != 9000 || len(obj.Routes) != 2 || obj.Routes[1] != "/b" {
+		t.Errorf("Load() = %+v, want Name MyApp, Port 9000 and Routes [/a /b]", *obj)
+	}
+}
+
+func Test_loader_RegisterFormat(t *testing.T) {
+	type Config struct {
+		ConfigFile string `confless:"file,format=kv"`
+		Name       string
+		Host       string
+		Port       int
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		fileOpts []fileOption
+		obj      *Config
+		wantErr  bool
+		want     Config
+	}{
+		{
+			name: "detect format by extension",
+			path: "config.kv",
+			obj:  &Config{},
+			want: Config{Name: "kv", Port: 1},
+		},
+		{
+			name:     "select format by name",
+			path:     "config.txt",
+			fileOpts: []fileOption{WithFileFormat("kv")},
+			obj:      &Config{},
+			want:     Config{Name: "txt"},
+		},
+		{
+			name: "select format by tag",
+			path: "config.kv",
+			obj:  &Config{ConfigFile: "host.txt"},
+			want: Config{ConfigFile: "host.txt", Name: "kv", Host: "tag", Port: 1},
+		},
+		{
+			name:     "unregistered format",
+			path:     "config.kv",
+			fileOpts: []fileOption{WithFileFormat("cue")},
+			obj:      &Config{},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "config.kv", []byte("name = kv\nport = 1"), 0644)
+			_ = afero.WriteFile(fs, "config.txt", []byte("name = txt"), 0644)
+			_ = afero.WriteFile(fs, "host.txt", []byte("host = tag"), 0644)
+
+			l := NewLoader(WithFS(fs))
+			l.RegisterFormat("kv", []string{".kv"}, newKeyValueDecoder)
 			l.RegisterFile(tt.path, tt.fileOpts...)
+
 			err := l.Load(tt.obj)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
 				return
 			}
-			if tt.verify != nil {
-				tt.verify(t, tt.obj)
+			if *tt.obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *tt.obj, tt.want)
 			}
 		})
 	}
 }
 
+func Test_loader_RegisterFormat_Override(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "config.json", []byte("name = kv"), 0644)
+
+	// Formats registered on a loader replace built-in formats only for this loader.
+	l := NewLoader(WithFS(fs))
+	l.RegisterFormat("kv", []string{".json"}, newKeyValueDecoder)
+	l.RegisterFile("config.json")
+
+	obj := &struct{ Name string }{}
+	err := l.Load(obj)
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if obj.Name != "kv" {
+		t.Errorf("expected Name to be 'kv', got '%s'", obj.Name)
+	}
+
+	other := NewLoader(WithFS(fs))
+	other.RegisterFile("config.json")
+	err = other.Load(obj)
+	if err == nil {
+		t.Error("Load() succeeded unexpectedly with the built-in JSON format")
+	}
+}
+
 func Test_loader_ConfigTag(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -361,6 +1270,64 @@ func Test_loader_ConfigTag(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "load from TOML file path in tagged field with automatic format detection",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "production.toml", []byte("name = \"ProductionApp\"\nport = 9000"), 0644)
+					return fs
+				}()),
+			},
+			obj: &struct {
+				ConfigFile string `confless:"file"`
+				Name       string
+				Port       int
+			}{
+				ConfigFile: "production.toml",
+			},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				v := reflect.ValueOf(obj).Elem()
+				name := v.FieldByName("Name").String()
+				port := int(v.FieldByName("Port").Int())
+				if name != "ProductionApp" {
+					t.Errorf("expected Name to be 'ProductionApp', got '%s'", name)
+				}
+				if port != 9000 {
+					t.Errorf("expected Port to be 9000, got %d", port)
+				}
+			},
+		},
+		{
+			name: "load from XML file path in tagged field with explicit format",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "production.conf", []byte(`<config port="9000"><name>ProductionApp</name></config>`), 0644)
+					return fs
+				}()),
+			},
+			obj: &struct {
+				ConfigFile string `confless:"file,format=xml"`
+				Name       string
+				Port       int `xml:"port,attr"`
+			}{
+				ConfigFile: "production.conf",
+			},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				v := reflect.ValueOf(obj).Elem()
+				name := v.FieldByName("Name").String()
+				port := int(v.FieldByName("Port").Int())
+				if name != "ProductionApp" {
+					t.Errorf("expected Name to be 'ProductionApp', got '%s'", name)
+				}
+				if port != 9000 {
+					t.Errorf("expected Port to be 9000, got %d", port)
+				}
+			},
+		},
 		{
 			name: "load from YAML file path in tagged field with automatic format detection",
 			opts: []loaderOption{
@@ -478,6 +1445,31 @@ func Test_loader_ConfigTag(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "skip ignored tagged field",
+			opts: []loaderOption{
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "ignored.json", []byte(`{"name": "IgnoredApp"}`), 0644)
+					return fs
+				}()),
+			},
+			obj: &struct {
+				ConfigFile string `confless:"file,-"`
+				Name       string
+			}{
+				ConfigFile: "ignored.json",
+			},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				// Use reflection to get values to avoid type assertion issues with tags
+				v := reflect.ValueOf(obj).Elem()
+				name := v.FieldByName("Name").String()
+				if name != "" {
+					t.Errorf("expected Name to be empty, got '%s'", name)
+				}
+			},
+		},
 		{
 			name: "skip missing file from tagged field",
 			opts: []loaderOption{
@@ -618,3 +1610,262 @@ func Test_loader_RegisterFlags(t *testing.T) {
 		})
 	}
 }
+
+func Test_loader_WithTagNames(t *testing.T) {
+	type Config struct {
+		Host       string `mapstructure:"host_name"`
+		Port       int    `json:"port"`
+		Internal   string `confless:"-"`
+		ConfigFile string `mapstructure:"config_file" confless:"file"`
+	}
+
+	tests := []struct {
+		name    string
+		opts    []loaderOption
+		obj     *Config
+		wantErr bool
+		verify  func(t *testing.T, obj *Config)
+	}{
+		{
+			name: "load file and env with custom tag names",
+			opts: []loaderOption{
+				WithTagNames("mapstructure"),
+				WithFS(func() afero.Fs {
+					fs := afero.NewMemMapFs()
+					_ = afero.WriteFile(fs, "config.json", []byte(`{"host_name": "localhost", "internal": "value"}`), 0644)
+					return fs
+				}()),
+				WithEnvReader(func() []string {
+					return []string{"APP_PORT=8080"}
+				}),
+			},
+			obj:     &Config{ConfigFile: "config.json"},
+			wantErr: false,
+			verify: func(t *testing.T, obj *Config) {
+				if obj.Host != "localhost" {
+					t.Errorf("expected Host to be 'localhost', got '%s'", obj.Host)
+				}
+				if obj.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", obj.Port)
+				}
+				if obj.Internal != "" {
+					t.Errorf("expected Internal to be empty, got '%s'", obj.Internal)
+				}
+			},
+		},
+		{
+			name: "error when field names are disabled",
+			opts: []loaderOption{
+				WithTagNames("mapstructure"),
+				WithFieldNames(false),
+				WithEnvReader(func() []string {
+					return []string{"APP_PORT=8080"}
+				}),
+			},
+			obj:     &Config{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoader(tt.opts...)
+			l.RegisterEnv("APP")
+			err := l.Load(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.verify != nil {
+				tt.verify(t, tt.obj)
+			}
+		})
+	}
+}
+
+func Test_loader_WithAmbiguityHandler(t *testing.T) {
+	type Config struct {
+		Port    int
+		AltPort int `json:"port"`
+	}
+
+	tests := []struct {
+		name    string
+		opts    []loaderOption
+		wantErr bool
+		verify  func(t *testing.T, obj *Config)
+	}{
+		{
+			name: "continue on ambiguous names by default",
+			opts: []loaderOption{
+				WithEnvReader(func() []string {
+					return []string{"APP_PORT=8080"}
+				}),
+			},
+			wantErr: false,
+			verify: func(t *testing.T, obj *Config) {
+				if obj.Port != 8080 || obj.AltPort != 0 {
+					t.Errorf("expected the first declared field to be set, got %d and %d", obj.Port, obj.AltPort)
+				}
+			},
+		},
+		{
+			name: "fail on ambiguous names with strict names",
+			opts: []loaderOption{
+				WithEnvReader(func() []string {
+					return []string{"APP_PORT=8080"}
+				}),
+				WithStrictNames(),
+			},
+			wantErr: true,
+			verify: func(t *testing.T, obj *Config) {
+				if obj.Port != 0 || obj.AltPort != 0 {
+					t.Errorf("expected no value to be set, got %d and %d", obj.Port, obj.AltPort)
+				}
+			},
+		},
+		{
+			name: "continue if handler returns nil",
+			opts: []loaderOption{
+				WithEnvReader(func() []string {
+					return []string{"APP_PORT=8080"}
+				}),
+				WithAmbiguityHandler(func(err error) error {
+					return nil
+				}),
+			},
+			wantErr: false,
+			verify: func(t *testing.T, obj *Config) {
+				if obj.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", obj.Port)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &Config{}
+			l := NewLoader(tt.opts...)
+			l.RegisterEnv("APP")
+			err := l.Load(obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrAmbiguousName) {
+				t.Errorf("Load() error = %v, want %v", err, ErrAmbiguousName)
+			}
+			if tt.verify != nil {
+				tt.verify(t, obj)
+			}
+		})
+	}
+}
+
+func Test_loader_WithKeyMatching(t *testing.T) {
+	type Config struct {
+		Port     int
+		MaxConns int `json:"max_conns"`
+	}
+
+	tests := []struct {
+		name    string
+		opts    []loaderOption
+		path    string
+		content string
+		env     []string
+		wantErr bool
+		want    Config
+	}{
+		{
+			name:    "match YAML keys case-insensitively by default",
+			path:    "config.yaml",
+			content: "Port: 8080\nMAX_CONNS: 10",
+			want:    Config{Port: 8080, MaxConns: 10},
+		},
+		{
+			name:    "ignore keys with different case in exact mode",
+			opts:    []loaderOption{WithKeyMatching(MatchExact)},
+			path:    "config.json",
+			content: `{"port": 8080, "max_conns": 10}`,
+			want:    Config{MaxConns: 10},
+		},
+		{
+			name:    "error for env var with different case in exact mode",
+			opts:    []loaderOption{WithKeyMatching(MatchExact)},
+			env:     []string{"APP_PORT=8080"},
+			wantErr: true,
+		},
+		{
+			name:    "match env var with same case in exact mode",
+			opts:    []loaderOption{WithKeyMatching(MatchExact)},
+			env:     []string{"app_Port=8080"},
+			want:    Config{Port: 8080},
+		},
+		{
+			name:    "match normalized keys",
+			opts:    []loaderOption{WithKeyMatching(MatchNormalized)},
+			path:    "config.yaml",
+			content: "port: 8080\nmax-conns: 10",
+			env:     []string{"APP_MAXCONNS=20"},
+			want:    Config{Port: 8080, MaxConns: 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.path != "" {
+				_ = afero.WriteFile(fs, tt.path, []byte(tt.content), 0644)
+			}
+
+			opts := append([]loaderOption{
+				WithFS(fs),
+				WithEnvReader(func() []string { return tt.env }),
+			}, tt.opts...)
+
+			obj := &Config{}
+			l := NewLoader(opts...)
+			if tt.path != "" {
+				l.RegisterFile(tt.path)
+			}
+			l.RegisterEnv("APP")
+			err := l.Load(obj)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && *obj != tt.want {
+				t.Errorf("Load() = %+v, want %+v", *obj, tt.want)
+			}
+		})
+	}
+}
+
+func Test_loader_WithEnvNaming(t *testing.T) {
+	type Config struct {
+		MaxIdleConns int
+	}
+
+	tests := []struct {
+		name     string
+		strategy naming.Strategy
+		env      []string
+		want     int
+	}{
+		{name: "screaming snake", strategy: NamingScreamingSnake, env: []string{"APP_MAX_IDLE_CONNS=5"}, want: 5},
+		{name: "lower", strategy: NamingLower, env: []string{"APP_MAXIDLECONNS=5"}, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLoader(WithEnvReader(func() []string { return tt.env }))
+			l.RegisterEnv("APP", WithEnvNaming(tt.strategy))
+
+			obj := &Config{}
+			err := l.Load(obj)
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if obj.MaxIdleConns != tt.want {
+				t.Errorf("expected MaxIdleConns to be %d, got %d", tt.want, obj.MaxIdleConns)
+			}
+		})
+	}
+}
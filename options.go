@@ -1,15 +1,94 @@
 package confless
 
-import "github.com/spf13/afero"
+import (
+	"github.com/spf13/afero"
+
+	"github.com/codetent/confless/pkg/dotpath"
+	"github.com/codetent/confless/pkg/naming"
+)
+
+const (
+	FileFormatJSON       fileFormat = "json"
+	FileFormatJSONC      fileFormat = "jsonc"
+	FileFormatJSON5      fileFormat = "json5"
+	FileFormatYAML       fileFormat = "yaml"
+	FileFormatTOML       fileFormat = "toml"
+	FileFormatHCL        fileFormat = "hcl"
+	FileFormatXML        fileFormat = "xml"
+	FileFormatINI        fileFormat = "ini"
+	FileFormatProperties fileFormat = "properties"
+	FileFormatCBOR       fileFormat = "cbor"
+	FileFormatMsgPack    fileFormat = "msgpack"
+	// Detected by the content (JSON, TOML or YAML), used for unknown extensions.
+	FileFormatAuto fileFormat = "auto"
+)
+
+const (
+	// Every underscore separates nested fields (e.g. "DB_MAX_CONNS" -> "db.max.conns").
+	EnvNestingUnderscore envNesting = "underscore"
+	// Underscores are matched against the field names, preferring the longest match (e.g. "DB_MAX_CONNS" -> "db.max_conns").
+	EnvNestingLongestMatch envNesting = "longest-match"
+	// Double underscores separate nested fields (e.g. "DB__MAX_CONNS" -> "db.max_conns").
+	EnvNestingDoubleUnderscore envNesting = "double-underscore"
+)
+
+const (
+	// Variables are kept in the environment (default).
+	EnvScrubNone envScrubbing = ""
+	// Variables mapped to fields marked as secret (e.g. `confless:"secret"`) are unset after loading.
+	EnvScrubSecrets envScrubbing = "secrets"
+	// All consumed variables are unset after loading.
+	EnvScrubConsumed envScrubbing = "consumed"
+)
 
 const (
-	FileFormatJSON fileFormat = "json"
-	FileFormatYAML fileFormat = "yaml"
+	// Loaded before the files (lowest precedence).
+	PrecedenceBeforeFiles precedence = iota
+	// Loaded after the files.
+	PrecedenceAfterFiles
+	// Loaded after the command-line flags.
+	PrecedenceAfterFlags
+	// Loaded after the environment variables.
+	PrecedenceAfterEnv
+)
+
+const (
+	MatchCaseInsensitive = dotpath.MatchCaseInsensitive
+	MatchExact           = dotpath.MatchExact
+	MatchNormalized      = dotpath.MatchNormalized
 )
 
 type loaderOption func(l *loader)
 type fileOption func(f *configFile)
 type fileFormat string
+type envOption func(e *envSource)
+type envNesting string
+type envScrubbing string
+type flagOption func(f *flagSource)
+type docOption func(d *docSource)
+type dotEnvOption func(d *dotEnvSource)
+type precedence int
+type dirOption func(d *dirSource)
+
+// Convert names to kebab-case (e.g. "MaxIdleConns" -> "max-idle-conns").
+func NamingKebab(name string) string {
+	return naming.Kebab(name)
+}
+
+// Convert names to snake_case (e.g. "MaxIdleConns" -> "max_idle_conns").
+func NamingSnake(name string) string {
+	return naming.Snake(name)
+}
+
+// Convert names to SCREAMING_SNAKE_CASE (e.g. "MaxIdleConns" -> "MAX_IDLE_CONNS").
+func NamingScreamingSnake(name string) string {
+	return naming.ScreamingSnake(name)
+}
+
+// Convert names to lowercase (e.g. "MaxIdleConns" -> "maxidleconns").
+func NamingLower(name string) string {
+	return naming.Lower(name)
+}
 
 // Set the file system to use.
 func WithFS(fs afero.Fs) loaderOption {
@@ -25,9 +104,170 @@ func WithEnvReader(reader func() []string) loaderOption {
 	}
 }
 
+// Set the function to unset environment variables with.
+// Defaults to os.Unsetenv.
+func WithEnvUnsetter(unsetter func(name string) error) loaderOption {
+	return func(l *loader) {
+		l.envUnsetter = unsetter
+	}
+}
+
+// Set the handler that is called with the names of the environment variables scrubbed while loading.
+func WithScrubHandler(handler func(names []string)) loaderOption {
+	return func(l *loader) {
+		l.scrubHandler = handler
+	}
+}
+
+// Set the tags to take field names from (in order of precedence).
+// Defaults to "json", "yaml", "toml" and "xml".
+func WithTagNames(tags ...string) loaderOption {
+	return func(l *loader) {
+		l.pathOpts = append(l.pathOpts, dotpath.WithTags(tags...))
+	}
+}
+
+// Set whether the names of struct fields are matched in addition to the tag names.
+// Enabled by default.
+func WithFieldNames(enabled bool) loaderOption {
+	return func(l *loader) {
+		l.pathOpts = append(l.pathOpts, dotpath.WithFieldNames(enabled))
+	}
+}
+
+// Set the policy to match keys of all sources against fields.
+// Defaults to MatchCaseInsensitive.
+func WithKeyMatching(m dotpath.Matching) loaderOption {
+	return func(l *loader) {
+		l.pathOpts = append(l.pathOpts, dotpath.WithMatching(m))
+	}
+}
+
+// Set the handler for ambiguous names, which match multiple fields of the object.
+// The names are checked before loading, loading fails with the returned error.
+// Return nil from the handler to continue loading (e.g. after logging a warning).
+// By default, names are not checked.
+func WithAmbiguityHandler(handler func(err error) error) loaderOption {
+	return func(l *loader) {
+		l.ambiguity = handler
+	}
+}
+
+// Fail loading if names are ambiguous, i.e. match multiple fields of the object.
+// The names are checked before loading, so no value is set on failure.
+func WithStrictNames() loaderOption {
+	return WithAmbiguityHandler(func(err error) error { return err })
+}
+
+// Select the documents of multi-document files (e.g. YAML) by the value of a top-level discriminator key.
+// Documents without the key are always applied, documents with the key only if set to the profile (or a list containing it).
+// Without a profile, all documents are applied as successive layers.
+func WithProfile(key string, value string) loaderOption {
+	return func(l *loader) {
+		l.profile = profile{key: key, value: value}
+	}
+}
+
 // Set the file format to use.
 func WithFileFormat(format fileFormat) fileOption {
 	return func(f *configFile) {
 		f.format = format
 	}
 }
+
+// Set how environment variable names are split into nested fields.
+func WithEnvNesting(nesting envNesting) envOption {
+	return func(e *envSource) {
+		e.nesting = nesting
+	}
+}
+
+// Allow to register environment variables without a prefix.
+// Only fields that declare an environment variable name (e.g. `confless:"env=PORT"`) are populated then.
+func WithUnprefixedEnv() envOption {
+	return func(e *envSource) {
+		e.unprefixed = true
+	}
+}
+
+// Set the naming strategy of environment variable names (e.g. NamingScreamingSnake).
+// Names are matched against the converted field names, preferring the longest match
+// (e.g. "APP_DB_MAX_IDLE_CONNS" -> "db.MaxIdleConns").
+func WithEnvNaming(strategy naming.Strategy) envOption {
+	return func(e *envSource) {
+		e.naming = strategy
+	}
+}
+
+// Set the naming strategy of flag names (e.g. NamingKebab).
+// Names are matched against the converted field names, preferring the longest match
+// (e.g. "db-max-idle-conns" -> "db.MaxIdleConns").
+func WithFlagNaming(strategy naming.Strategy) flagOption {
+	return func(f *flagSource) {
+		f.naming = strategy
+	}
+}
+
+// Enable reading values from files referenced by variables with the "_FILE" suffix.
+// For example, "APP_DB_PASSWORD_FILE=/run/secrets/db" sets the field of "APP_DB_PASSWORD" to the content of the file.
+// Variables with the suffix are only treated as references if the name without the suffix matches a field.
+func WithEnvFiles() envOption {
+	return func(e *envSource) {
+		e.files = true
+	}
+}
+
+// Set the separator of nested keys in file names of a directory.
+// Defaults to "." (e.g. "database.host"), use "__" for file names like "database__host".
+func WithDirSeparator(sep string) dirOption {
+	return func(d *dirSource) {
+		d.separator = sep
+	}
+}
+
+// Set the environment variable to locate the directory by.
+func withDirPathEnv(name string) dirOption {
+	return func(d *dirSource) {
+		d.pathEnv = name
+	}
+}
+
+// Set which consumed variables are unset from the environment after loading them,
+// so that they are not inherited by child processes.
+func WithEnvScrubbing(scrubbing envScrubbing) envOption {
+	return func(e *envSource) {
+		e.scrubbing = scrubbing
+	}
+}
+
+// Set the format of the document.
+// Defaults to detection by the content (same rules as files with unknown extensions).
+func WithDocumentFormat(format fileFormat) docOption {
+	return func(d *docSource) {
+		d.format = format
+	}
+}
+
+// Decode the document from base64 before loading it.
+func WithDocumentBase64() docOption {
+	return func(d *docSource) {
+		d.base64 = true
+	}
+}
+
+// Set when the document is loaded relative to the other sources.
+// Defaults to PrecedenceAfterFiles.
+func WithDocumentPrecedence(p precedence) docOption {
+	return func(d *docSource) {
+		d.precedence = p
+	}
+}
+
+// Load the local and profile specific files after the dotenv file (e.g. ".env" -> ".env.local" -> ".env.production").
+// The profile is optional, later files take precedence.
+func WithDotEnvCascade(profile string) dotEnvOption {
+	return func(d *dotEnvSource) {
+		d.cascade = true
+		d.profile = profile
+	}
+}
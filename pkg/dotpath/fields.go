@@ -0,0 +1,344 @@
+package dotpath
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/codetent/confless/pkg/reflectutil"
+)
+
+var (
+	ErrAmbiguousName = errors.New("ambiguous name")
+	ErrFieldNotFound = errors.New("field not found")
+
+	xmlNameType = reflect.TypeOf(xml.Name{})
+)
+
+// A field of a struct that can be addressed by name.
+type field struct {
+	name   string   // primary name used to resolve conflicts
+	names  []string // all names the field can be addressed by (normalized)
+	raw    []string // all names the field can be addressed by (as declared)
+	index  []int    // index sequence for reflect.Value.FieldByIndex
+	tagged bool     // whether the name is taken from a tag
+}
+
+// Key of the field cache.
+type fieldCacheKey struct {
+	typ reflect.Type
+	opt string
+}
+
+// Cache of analyzed struct types.
+var fieldCache sync.Map // map[fieldCacheKey][]field
+
+// Checks whether the field is excluded by a tag.
+// Fields are excluded by `confless:"-"`, `json:"-"` or `yaml:"-"`, a "-" name in other tags (e.g. `xml:"-"`) is not.
+// Element names of XML documents (xml.Name fields like XMLName) are always excluded.
+func isIgnored(f reflect.StructField) bool {
+	if reflectutil.ParseTag(f.Tag, "confless")["-"] != "" || f.Type == xmlNameType {
+		return true
+	}
+
+	for _, tag := range []string{"json", "yaml"} {
+		// Note that "-," names the field "-" like encoding/json does.
+		if f.Tag.Get(tag) == "-" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Extract names from tags.
+// Tags without a name (e.g. `xml:",chardata"`) or excluding the field from their format (e.g. `xml:"-"`) are skipped.
+func namesFromTags(f reflect.StructField, o *options) []string {
+	names := make([]string, 0, len(o.tags))
+
+	for _, tag := range o.tags {
+		value := f.Tag.Get(tag)
+		if value == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(value, ",")
+		if tag == "xml" {
+			name = xmlTagName(name)
+		}
+
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Returns the local name of an xml tag name (e.g. "urn:app host" -> "host").
+// Paths of nested elements (e.g. "server>port") are no names.
+func xmlTagName(name string) string {
+	if strings.Contains(name, ">") {
+		return ""
+	}
+
+	_, local, ok := strings.Cut(name, " ")
+	if ok {
+		return local
+	}
+
+	return name
+}
+
+// Checks whether the field is marked to be inlined into its parent.
+func isInline(f reflect.StructField) bool {
+	if reflectutil.ParseTag(f.Tag, "confless")["inline"] != "" {
+		return true
+	}
+
+	// Support the inline option of yaml tags (e.g. `yaml:",inline"`).
+	opts := strings.Split(f.Tag.Get("yaml"), ",")
+	return slices.Contains(opts[1:], "inline")
+}
+
+// Returns the addressable fields of the given struct type.
+// Embedded structs and fields marked as inline are flattened into their parent, ignored fields are skipped.
+// Conflicting names are resolved like encoding/json does: the shallowest field wins,
+// fields with a name from a tag win over untagged ones, otherwise all are dropped.
+func typeFields(t reflect.Type, o *options) []field {
+	key := fieldCacheKey{typ: t, opt: o.key()}
+	if cached, ok := fieldCache.Load(key); ok {
+		return cached.([]field)
+	}
+
+	// Collect all fields including the ones of flattened structs.
+	var all []field
+	collectFields(t, nil, map[reflect.Type]bool{}, &all, o)
+
+	// Group the fields by their primary name.
+	byName := make(map[string][]field)
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	fields := make([]field, 0, len(byName))
+	for _, f := range all {
+		group := byName[f.name]
+		if group == nil {
+			// Already processed.
+			continue
+		}
+		byName[f.name] = nil
+
+		if dominant, ok := dominantField(group); ok {
+			fields = append(fields, dominant)
+		}
+	}
+
+	// Keep the order of declaration.
+	slices.SortFunc(fields, func(a, b field) int {
+		return slices.Compare(a.index, b.index)
+	})
+
+	fieldCache.Store(key, fields)
+	return fields
+}
+
+// Collects the fields of the given struct type recursively.
+func collectFields(t reflect.Type, index []int, visited map[reflect.Type]bool, out *[]field, o *options) {
+	// Prevent endless recursion for recursive types.
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if isIgnored(sf) {
+			continue
+		}
+
+		tagNames := namesFromTags(sf, o)
+
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		// Find the struct type of embedded or inlined fields.
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous {
+			// Skip unexported non-struct types and unexported pointers (cannot be allocated).
+			if !sf.IsExported() && (ft.Kind() != reflect.Struct || sf.Type.Kind() == reflect.Pointer) {
+				continue
+			}
+		} else if !sf.IsExported() {
+			// Skip unexported fields.
+			continue
+		}
+
+		// Flatten embedded structs without a name and inlined structs.
+		if ft.Kind() == reflect.Struct && ((sf.Anonymous && len(tagNames) == 0) || isInline(sf)) {
+			collectFields(ft, idx, visited, out, o)
+			continue
+		}
+
+		f := field{
+			name:   sf.Name,
+			names:  tagNames,
+			index:  idx,
+			tagged: len(tagNames) > 0,
+		}
+		if f.tagged {
+			f.name = tagNames[0]
+		}
+
+		// Match the name of the struct field if enabled.
+		if o.fieldNames {
+			f.names = append([]string{sf.Name}, tagNames...)
+		}
+
+		// Replace the names by the prefix if enabled.
+		if prefix := reflectutil.ParseTag(sf.Tag, "confless")["prefix"]; o.prefixes && prefix != "" {
+			f.name = prefix
+			f.names = []string{prefix}
+			f.tagged = true
+		}
+
+		// Normalize the names according to the matching policy.
+		f.raw = f.names
+		names := make([]string, 0, len(f.names))
+		for _, name := range f.names {
+			name = o.matching.normalize(name)
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		f.names = names
+
+		*out = append(*out, f)
+	}
+}
+
+// Returns the field that dominates the others with the same name.
+func dominantField(fields []field) (field, bool) {
+	// Only the shallowest fields are candidates.
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		depth = min(depth, len(f.index))
+	}
+
+	candidates := slices.DeleteFunc(slices.Clone(fields), func(f field) bool {
+		return len(f.index) > depth
+	})
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+
+	// A single tagged field wins over untagged ones.
+	candidates = slices.DeleteFunc(candidates, func(f field) bool {
+		return !f.tagged
+	})
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+
+	return field{}, false
+}
+
+// Returns the field of the struct at the given index sequence.
+// Nil pointers to embedded structs are resolved according to the access if possible.
+func fieldByIndex(v reflect.Value, index []int, a access) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			elem, ok := derefValue(v, a)
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("embedded struct is nil: %s", v.Type().Elem())
+			}
+
+			v = elem
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, nil
+}
+
+// Cache of checked types.
+var checkCache sync.Map // map[fieldCacheKey]error
+
+// Checks the given type and all nested types for names that match multiple fields.
+func checkType(t reflect.Type, o *options) error {
+	key := fieldCacheKey{typ: t, opt: o.key()}
+	if cached, ok := checkCache.Load(key); ok {
+		if cached == nil {
+			return nil
+		}
+
+		return cached.(error)
+	}
+
+	errs := make([]error, 0)
+	checkTypeLevel(t, "", map[reflect.Type]bool{}, &errs, o)
+	err := errors.Join(errs...)
+
+	checkCache.Store(key, err)
+	return err
+}
+
+// Checks the names of a single type recursively.
+func checkTypeLevel(t reflect.Type, path string, visited map[reflect.Type]bool, errs *[]error, o *options) {
+	// Unwrap the type of pointers and collections.
+	for slices.Contains([]reflect.Kind{reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map}, t.Kind()) {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+
+	fields := typeFields(t, o)
+
+	// Group the fields by the names matching them.
+	byName := make(map[string][]string)
+	order := make([]string, 0)
+	for _, f := range fields {
+		for _, name := range f.names {
+			if byName[name] == nil {
+				order = append(order, name)
+			}
+			byName[name] = append(byName[name], t.FieldByIndex(f.index).Name)
+		}
+	}
+
+	for _, name := range order {
+		if len(byName[name]) > 1 {
+			err := fmt.Errorf("%w: %q matches fields %s", ErrAmbiguousName, name, strings.Join(byName[name], ", "))
+			if path != "" {
+				err = fmt.Errorf("%s: %w", path, err)
+			}
+
+			*errs = append(*errs, err)
+		}
+	}
+
+	// Check the nested types.
+	for _, f := range fields {
+		p := f.name
+		if path != "" {
+			p = path + "." + p
+		}
+
+		checkTypeLevel(t.FieldByIndex(f.index).Type, p, visited, errs, o)
+	}
+}
@@ -0,0 +1,185 @@
+package dotpath
+
+import (
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_typeFields(t *testing.T) {
+	type Common struct {
+		Name  string
+		Level string `json:"level"`
+	}
+
+	type Other struct {
+		Level string `yaml:"level"`
+	}
+
+	tests := []struct {
+		name string
+		t    reflect.Type
+		opts []Option
+		want []string
+	}{
+		{
+			name: "plain fields",
+			t: reflect.TypeOf(struct {
+				Name string
+				Port int `json:"port"`
+			}{}),
+			want: []string{"Name", "port"},
+		},
+		{
+			name: "skip unexported and ignored fields",
+			t: reflect.TypeOf(struct {
+				Name     string
+				internal string
+				Ignored  string `confless:"-"`
+				Secret   string `json:"-"`
+				Hidden   string `yaml:"-"`
+			}{}),
+			want: []string{"Name"},
+		},
+		{
+			name: "keep fields with dash in other tags",
+			t: reflect.TypeOf(struct {
+				Name  string `xml:"-"`
+				Port  int    `toml:"-"`
+				Debug bool   `json:"debug" xml:"-"`
+			}{}),
+			want: []string{"Name", "Port", "debug"},
+		},
+		{
+			name: "flatten embedded struct",
+			t: reflect.TypeOf(struct {
+				Common
+				Port int
+			}{}),
+			want: []string{"Name", "level", "Port"},
+		},
+		{
+			name: "drop conflicting fields on the same level",
+			t: reflect.TypeOf(struct {
+				Common
+				Other
+			}{}),
+			want: []string{"Name"},
+		},
+		{
+			name: "skip XML element names and xml tags without a name",
+			t: reflect.TypeOf(struct {
+				XMLName xml.Name `xml:"config"`
+				ID      string   `xml:"id,attr"`
+				Text    string   `xml:",chardata"`
+				Host    string   `xml:"urn:app host"`
+				Port    int      `xml:"server>port"`
+			}{}),
+			want: []string{"id", "Text", "host", "Port"},
+		},
+		{
+			name: "skip XML element names without the xml tag",
+			t: reflect.TypeOf(struct {
+				XMLName xml.Name
+				Name    string `xml:"name"`
+			}{}),
+			opts: []Option{WithTags("json")},
+			want: []string{"Name"},
+		},
+		{
+			name: "take primary name from configured tags",
+			t: reflect.TypeOf(struct {
+				Host string `json:"host" toml:"hostname"`
+			}{}),
+			opts: []Option{WithTags("toml", "json")},
+			want: []string{"hostname"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, f := range typeFields(tt.t, newOptions(tt.opts...)) {
+				got = append(got, f.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("typeFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkType(t *testing.T) {
+	type Nested struct {
+		Port    int
+		AltPort int `json:"PORT"`
+	}
+
+	tests := []struct {
+		name     string
+		t        reflect.Type
+		opts     []Option
+		wantErrs []string
+	}{
+		{
+			name: "no ambiguous names",
+			t: reflect.TypeOf(struct {
+				Name string `json:"name"`
+				Port int
+			}{}),
+		},
+		{
+			name: "field name matches tag of other field",
+			t: reflect.TypeOf(struct {
+				Port    int
+				AltPort string `json:"port"`
+			}{}),
+			wantErrs: []string{`"port" matches fields Port, AltPort`},
+		},
+		{
+			name: "tags of different fields collide",
+			t: reflect.TypeOf(struct {
+				Host  string `json:"host"`
+				Other string `yaml:"HOST"`
+			}{}),
+			wantErrs: []string{`"host" matches fields Host, Other`},
+		},
+		{
+			name: "report nested level",
+			t: reflect.TypeOf(struct {
+				Items []Nested `json:"items"`
+			}{}),
+			wantErrs: []string{`items: ambiguous name: "port" matches fields Port, AltPort`},
+		},
+		{
+			name: "no conflict without field names",
+			t: reflect.TypeOf(struct {
+				Port    int
+				AltPort string `json:"port"`
+			}{}),
+			opts: []Option{WithFieldNames(false)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkType(tt.t, newOptions(tt.opts...))
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Errorf("checkType() failed: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAmbiguousName) {
+				t.Fatalf("checkType() error = %v, want %v", err, ErrAmbiguousName)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("checkType() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package dotpath
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// Match names case-insensitively (e.g. "port" matches "Port").
+	MatchCaseInsensitive Matching = iota
+	// Match names exactly (e.g. "port" only matches "port").
+	MatchExact
+	// Match names ignoring case, dashes and underscores (e.g. "max_conns" matches "MaxConns" and "max-conns").
+	MatchNormalized
+)
+
+// Policy to match names against fields.
+type Matching int
+
+// Option to configure how names are resolved to fields.
+type Option func(o *options)
+
+type options struct {
+	tags       []string
+	fieldNames bool
+	matching   Matching
+	prefixes   bool
+	naming     func(name string) string
+}
+
+// Returns the normalized name used for comparison according to the policy.
+func (m Matching) normalize(name string) string {
+	switch m {
+	case MatchExact:
+		return name
+	case MatchNormalized:
+		name = strings.ReplaceAll(name, "_", "")
+		name = strings.ReplaceAll(name, "-", "")
+		return strings.ToLower(name)
+	default:
+		return strings.ToLower(name)
+	}
+}
+
+// Creates the options by applying the given options to the defaults.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		tags:       []string{"json", "yaml", "toml", "xml"},
+		fieldNames: true,
+	}
+
+	// Apply the given options.
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Returns a key identifying the options.
+func (o *options) key() string {
+	return strings.Join(o.tags, ",") + ";" + strconv.FormatBool(o.fieldNames) + ";" + strconv.Itoa(int(o.matching)) + ";" + strconv.FormatBool(o.prefixes)
+}
+
+// Set the tags to take field names from (in order of precedence).
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = tags
+	}
+}
+
+// Set whether the names of struct fields are matched.
+func WithFieldNames(enabled bool) Option {
+	return func(o *options) {
+		o.fieldNames = enabled
+	}
+}
+
+// Set the policy to match names against fields.
+func WithMatching(m Matching) Option {
+	return func(o *options) {
+		o.matching = m
+	}
+}
+
+// Set whether the prefix of the confless tag (e.g. `confless:"prefix=db"`) replaces the names of a field.
+// This allows to rename whole subtrees for sources like environment variables and flags.
+func WithPrefixes(enabled bool) Option {
+	return func(o *options) {
+		o.prefixes = enabled
+	}
+}
+
+// Set the naming strategy to convert field names before matching them (e.g. "MaxConns" -> "max-conns").
+// Names are matched as declared as well.
+func WithNaming(naming func(name string) string) Option {
+	return func(o *options) {
+		o.naming = naming
+	}
+}
@@ -2,12 +2,14 @@ package dotpath
 
 import (
 	"fmt"
+	"iter"
 	"reflect"
 )
 
 // Get the value at the given path of the object.
-func Get(obj any, p string) (any, error) {
-	refField, err := getValue(reflect.ValueOf(obj), p)
+// The object is not changed, paths through nil pointers or beyond the length of slices are errors.
+func Get(obj any, p string, opts ...Option) (any, error) {
+	refField, err := getValue(reflect.ValueOf(obj), p, accessRead, newOptions(opts...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get field: %w", err)
 	}
@@ -16,8 +18,9 @@ func Get(obj any, p string) (any, error) {
 }
 
 // Set the value at the given path of the object.
-func Set(obj any, p string, v any) error {
-	refField, err := getValue(reflect.ValueOf(obj), p)
+// Nil pointers on the path are allocated and slices are extended by an index equal to their length (see Resolve).
+func Set(obj any, p string, v any, opts ...Option) error {
+	refField, err := resolveValue(reflect.ValueOf(obj), p, newOptions(opts...))
 	if err != nil {
 		return fmt.Errorf("failed to get field: %w", err)
 	}
@@ -29,3 +32,149 @@ func Set(obj any, p string, v any) error {
 
 	return nil
 }
+
+// Set the value at the path given by the parts of the object.
+// Consecutive parts joined by the separator are matched against field names, preferring the longest match.
+// For example, the parts ["db", "max", "conns"] with the separator "_" resolve to the path "db.max_conns".
+func SetParts(obj any, parts []string, sep string, v any, opts ...Option) error {
+	refField, err := resolveValueByParts(reflect.ValueOf(obj), parts, sep, newOptions(opts...))
+	if err != nil {
+		return fmt.Errorf("failed to get field: %w", err)
+	}
+
+	err = setValue(refField, v)
+	if err != nil {
+		return fmt.Errorf("failed to set field: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the field at the given path of the object.
+// The object is not changed, paths through nil pointers or beyond the length of slices are errors (see Resolve).
+func Lookup(obj any, p string, opts ...Option) (reflect.Value, error) {
+	refField, err := getValue(reflect.ValueOf(obj), p, accessRead, newOptions(opts...))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to get field: %w", err)
+	}
+
+	return refField, nil
+}
+
+// Returns the field at the path given by the parts of the object (see SetParts).
+// The object is not changed (see ResolveParts).
+func LookupParts(obj any, parts []string, sep string, opts ...Option) (reflect.Value, error) {
+	refField, _, err := getValueByParts(reflect.ValueOf(obj), parts, sep, accessRead, newOptions(opts...))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to get field: %w", err)
+	}
+
+	return refField, nil
+}
+
+// Returns the field at the given path of the object to be set by SetValue.
+// Nil pointers on the path are allocated and slices are extended by one element if the index equals their length.
+// The object is only changed if the whole path resolves.
+func Resolve(obj any, p string, opts ...Option) (reflect.Value, error) {
+	refField, err := resolveValue(reflect.ValueOf(obj), p, newOptions(opts...))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to get field: %w", err)
+	}
+
+	return refField, nil
+}
+
+// Returns the field at the path given by the parts of the object to be set by SetValue (see SetParts and Resolve).
+func ResolveParts(obj any, parts []string, sep string, opts ...Option) (reflect.Value, error) {
+	refField, err := resolveValueByParts(reflect.ValueOf(obj), parts, sep, newOptions(opts...))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to get field: %w", err)
+	}
+
+	return refField, nil
+}
+
+// Set the given value to the field.
+// The value is converted to the type of the field the same way as for Set.
+func SetValue(field reflect.Value, v any) error {
+	err := setValue(field, v)
+	if err != nil {
+		return fmt.Errorf("failed to set field: %w", err)
+	}
+
+	return nil
+}
+
+// Decode the given data into the object.
+// The data is expected to consist of maps, slices and basic values (e.g. as decoded from a file).
+// Keys are resolved to fields the same way as path parts are (unknown keys are ignored).
+func Decode(obj any, data any, opts ...Option) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("object is not a pointer")
+	}
+
+	err := decodeValue(v.Elem(), data, newOptions(opts...))
+	if err != nil {
+		return fmt.Errorf("failed to decode: %w", err)
+	}
+
+	return nil
+}
+
+// Returns a sequence of the addressable fields of the given struct and their values.
+// Embedded and inlined structs are flattened and ignored fields are skipped (same as for paths).
+// Fields behind nil pointers to embedded structs are skipped as well.
+func Fields(s reflect.Value, opts ...Option) iter.Seq2[reflect.StructField, reflect.Value] {
+	o := newOptions(opts...)
+
+	return func(yield func(reflect.StructField, reflect.Value) bool) {
+		for _, f := range typeFields(s.Type(), o) {
+			v, err := s.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+
+			if !yield(s.Type().FieldByIndex(f.index), v) {
+				return
+			}
+		}
+	}
+}
+
+// Returns a sequence of the primary names of the addressable fields of the given struct type and the fields.
+// Embedded and inlined structs are flattened and ignored fields are skipped (same as for paths).
+func TypeFields(t reflect.Type, opts ...Option) iter.Seq2[string, reflect.StructField] {
+	o := newOptions(opts...)
+
+	return func(yield func(string, reflect.StructField) bool) {
+		for _, f := range typeFields(t, o) {
+			if !yield(f.name, t.FieldByIndex(f.index)) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the field of the given struct type with the given name (matched the same way as path parts).
+func TypeField(t reflect.Type, name string, opts ...Option) (reflect.StructField, bool) {
+	f, ok := findField(t, name, newOptions(opts...))
+	if !ok {
+		return reflect.StructField{}, false
+	}
+
+	return t.FieldByIndex(f.index), true
+}
+
+// Check the type of the object for names that match multiple fields.
+// Nested types are checked as well. Conflicts are listed per nesting level.
+func Check(obj any, opts ...Option) error {
+	return checkType(reflect.TypeOf(obj), newOptions(opts...))
+}
+
+// Check whether the names match according to the configured policy (e.g. "Profile" and "profile").
+func MatchName(a string, b string, opts ...Option) bool {
+	o := newOptions(opts...)
+
+	return o.matching.normalize(a) == o.matching.normalize(b)
+}
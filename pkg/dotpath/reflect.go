@@ -1,84 +1,102 @@
 package dotpath
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/goccy/go-yaml"
 	"github.com/spf13/cast"
 )
 
-// Extract names from tags.
-func namesFromTags(f reflect.StructField) []string {
-	names := make([]string, 0, 2)
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+)
 
-	for _, tag := range []string{"json", "yaml"} {
-		tag := strings.SplitN(f.Tag.Get(tag), ",", 2)
-		if len(tag) > 0 && tag[0] != "" {
-			names = append(names, tag[0])
-		}
+// Returns the field with the given name (matched according to the policy).
+// Fields of embedded and inlined structs are promoted to the given struct.
+func structField(s reflect.Value, n string, a access, o *options) (reflect.Value, error) {
+	f, ok := findField(s.Type(), n, o)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w: %s", ErrFieldNotFound, n)
 	}
 
-	return names
+	return fieldByIndex(s, f.index, a)
 }
 
-// Returns the field with the given name (case-insensitive).
-func structField(s reflect.Value, n string) (reflect.Value, error) {
-	for i := 0; i < s.NumField(); i++ {
-		// Take the name from the struct field.
-		fieldType := s.Type().Field(i)
-		names := []string{fieldType.Name}
-
-		// Extract names from tags.
-		tagNames := namesFromTags(fieldType)
-		names = append(names, tagNames...)
+// Returns the field of the struct type with the given name (matched according to the policy).
+func findField(t reflect.Type, n string, o *options) (field, bool) {
+	key := o.matching.normalize(n)
 
+	for _, f := range typeFields(t, o) {
 		// Compare the names with the given name.
-		for _, name := range names {
-			if strings.EqualFold(name, n) {
-				return s.Field(i), nil
+		if slices.Contains(f.names, key) {
+			return f, true
+		}
+
+		// Compare the names converted by the naming strategy.
+		if o.naming != nil {
+			for _, name := range f.raw {
+				if o.matching.normalize(o.naming(name)) == key {
+					return f, true
+				}
 			}
 		}
 	}
 
-	return reflect.Value{}, fmt.Errorf("field not found: %s", n)
+	return field{}, false
 }
 
+// Access to values while traversing paths.
+type access int
+
+const (
+	// Values are only read, nil pointers and missing elements are errors.
+	accessRead access = iota
+	// Nil pointers and appended elements are resolved through temporary values (the object is not changed).
+	accessProbe
+	// Nil pointers are allocated and elements are appended to slices.
+	accessAlloc
+)
+
 // Returns the value at the given path.
-func getValue(v reflect.Value, p string) (reflect.Value, error) {
-	parts := strings.Split(p, ".")
+func getValue(v reflect.Value, p string, a access, o *options) (reflect.Value, error) {
+	return getValueAt(v, strings.Split(p, "."), a, o)
+}
+
+// Returns the value at the path given by its names.
+func getValueAt(v reflect.Value, parts []string, a access, o *options) (reflect.Value, error) {
+	p := strings.Join(parts, ".")
 
 	// Traverse the path.
 	for len(parts) > 0 {
 		// If the value is a pointer, dereference it.
-		for v.Kind() == reflect.Pointer {
-			if v.IsNil() {
-				return reflect.Value{}, fmt.Errorf("value is nil at path: %s", p)
-			}
-
-			v = v.Elem()
+		var ok bool
+		v, ok = derefValue(v, a)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("value is nil at path: %s", p)
 		}
 
 		switch v.Kind() {
 		case reflect.Struct:
 			var err error
-			v, err = structField(v, parts[0])
+			v, err = structField(v, parts[0], a, o)
 			if err != nil {
 				return reflect.Value{}, fmt.Errorf("failed to get field: %w", err)
 			}
 		case reflect.Array, reflect.Slice:
-			index, err := strconv.Atoi(parts[0])
+			var err error
+			v, err = indexValue(v, parts[0], a)
 			if err != nil {
-				return reflect.Value{}, fmt.Errorf("invalid index: %s", parts[0])
+				return reflect.Value{}, err
 			}
-
-			if index < 0 || index >= v.Len() {
-				return reflect.Value{}, fmt.Errorf("index out of bounds: %s", parts[0])
-			}
-
-			v = v.Index(index)
 		default:
 			return reflect.Value{}, fmt.Errorf("unsupported type: %s", v.Kind())
 		}
@@ -90,6 +108,128 @@ func getValue(v reflect.Value, p string) (reflect.Value, error) {
 	return v, nil
 }
 
+// Returns the value at the given path to be set.
+// Nil pointers are allocated and elements appended only if the whole path resolves.
+func resolveValue(v reflect.Value, p string, o *options) (reflect.Value, error) {
+	parts := strings.Split(p, ".")
+
+	_, err := getValueAt(v, parts, accessProbe, o)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return getValueAt(v, parts, accessAlloc, o)
+}
+
+// Returns the value at the path given by the parts and the names of the path it resolved to.
+// Consecutive parts joined by the separator are matched against field names,
+// preferring the longest match (e.g. ["db", "max", "conns"] with "_" resolves to "db" and "max_conns").
+func getValueByParts(v reflect.Value, parts []string, sep string, a access, o *options) (reflect.Value, []string, error) {
+	if len(parts) == 0 {
+		return v, nil, nil
+	}
+
+	// If the value is a pointer, dereference it.
+	v, ok := derefValue(v, a)
+	if !ok {
+		return reflect.Value{}, nil, fmt.Errorf("value is nil at path: %s", strings.Join(parts, sep))
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		// Try the longest name first and backtrack if the remaining parts cannot be resolved.
+		var err error
+		for n := len(parts); n > 0; n-- {
+			name := strings.Join(parts[:n], sep)
+
+			var f reflect.Value
+			f, err = structField(v, name, a, o)
+			if err != nil {
+				continue
+			}
+
+			var names []string
+			f, names, err = getValueByParts(f, parts[n:], sep, a, o)
+			if err == nil {
+				return f, append([]string{name}, names...), nil
+			}
+		}
+
+		return reflect.Value{}, nil, fmt.Errorf("failed to get field: %w", err)
+	case reflect.Array, reflect.Slice:
+		elem, err := indexValue(v, parts[0], a)
+		if err != nil {
+			return reflect.Value{}, nil, err
+		}
+
+		elem, names, err := getValueByParts(elem, parts[1:], sep, a, o)
+		if err != nil {
+			return reflect.Value{}, nil, err
+		}
+
+		return elem, append([]string{parts[0]}, names...), nil
+	default:
+		return reflect.Value{}, nil, fmt.Errorf("unsupported type: %s", v.Kind())
+	}
+}
+
+// Returns the value at the path given by the parts to be set (see resolveValue).
+// Backtracking happens while probing, only the resolved path is allocated.
+func resolveValueByParts(v reflect.Value, parts []string, sep string, o *options) (reflect.Value, error) {
+	_, names, err := getValueByParts(v, parts, sep, accessProbe, o)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return getValueAt(v, names, accessAlloc, o)
+}
+
+// Dereferences the value if it is a pointer.
+// Nil pointers are resolved according to the access if settable, otherwise false is returned.
+func derefValue(v reflect.Value, a access) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if a == accessRead || !v.CanSet() {
+				return reflect.Value{}, false
+			}
+
+			elem := reflect.New(v.Type().Elem())
+			if a == accessAlloc {
+				v.Set(elem)
+			}
+
+			v = elem
+		}
+
+		v = v.Elem()
+	}
+
+	return v, true
+}
+
+// Returns the element of the slice or array at the given index.
+// Settable slices are extended by one element according to the access if the index equals their length.
+func indexValue(v reflect.Value, i string, a access) (reflect.Value, error) {
+	index, err := strconv.Atoi(i)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid index: %s", i)
+	}
+
+	if a != accessRead && v.Kind() == reflect.Slice && index == v.Len() && v.CanSet() {
+		if a == accessProbe {
+			return reflect.New(v.Type().Elem()).Elem(), nil
+		}
+
+		v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+	}
+
+	if index < 0 || index >= v.Len() {
+		return reflect.Value{}, fmt.Errorf("index out of bounds: %s", i)
+	}
+
+	return v.Index(index), nil
+}
+
 func setValue(v reflect.Value, value any) error {
 	// If the value is a pointer, dereference it.
 	for v.Kind() == reflect.Pointer {
@@ -116,6 +256,28 @@ func setValue(v reflect.Value, value any) error {
 		return nil
 	}
 
+	// If the value is an encoding.TextUnmarshaler, use it to unmarshal strings (e.g. net.IP).
+	if s, ok := value.(string); ok {
+		if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			err := unmarshaler.UnmarshalText([]byte(s))
+			if err != nil {
+				return fmt.Errorf("failed to unmarshal value: %w", err)
+			}
+			return nil
+		}
+
+		// Parse durations with units (e.g. "5s").
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("failed to parse duration: %w", err)
+			}
+
+			v.SetInt(int64(d))
+			return nil
+		}
+	}
+
 	// Handle basic types.
 	switch v.Kind() {
 	case reflect.String:
@@ -159,3 +321,205 @@ func setValue(v reflect.Value, value any) error {
 
 	return nil
 }
+
+// Decodes the generic data (maps, slices and basic values) into the given value.
+func decodeValue(v reflect.Value, data any, o *options) error {
+	// Skip null values.
+	if data == nil {
+		return nil
+	}
+
+	// If the value is a pointer, allocate and dereference it.
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		v = v.Elem()
+	}
+
+	// If the value is a YAML unmarshaler, let it decode the data encoded as YAML.
+	if isYAMLUnmarshaler(v.Addr().Interface()) {
+		b, err := yaml.Marshal(plainData(data))
+		if err != nil {
+			return fmt.Errorf("failed to marshal value: %w", err)
+		}
+
+		err = yaml.Unmarshal(b, v.Addr().Interface())
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+		return nil
+	}
+
+	// If the value is a json.Unmarshaler, let it decode the data.
+	if _, ok := v.Addr().Interface().(json.Unmarshaler); ok {
+		return setValue(v, data)
+	}
+
+	// If the value is an encoding.TextUnmarshaler, let it decode strings.
+	if _, ok := data.(string); ok {
+		if _, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return setValue(v, data)
+		}
+	}
+
+	d := reflect.ValueOf(data)
+
+	switch v.Kind() {
+	case reflect.Interface:
+		// Numbers are set as float64 like encoding/json does.
+		d = reflect.ValueOf(interfaceData(data))
+		if !d.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("cannot assign %s to %s", d.Type(), v.Type())
+		}
+
+		v.Set(d)
+	case reflect.Struct:
+		if d.Kind() != reflect.Map {
+			return fmt.Errorf("cannot decode %s into struct", d.Kind())
+		}
+
+		iter := d.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+
+			// Skip unknown keys.
+			f, err := structField(v, key, accessAlloc, o)
+			if err != nil {
+				continue
+			}
+
+			err = decodeValue(f, iter.Value().Interface(), o)
+			if err != nil {
+				return fmt.Errorf("failed to decode field %s: %w", key, err)
+			}
+		}
+	case reflect.Map:
+		if d.Kind() != reflect.Map {
+			return fmt.Errorf("cannot decode %s into map", d.Kind())
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), d.Len()))
+		}
+
+		iter := d.MapRange()
+		for iter.Next() {
+			key := reflect.New(v.Type().Key()).Elem()
+			err := setValue(key, iter.Key().Interface())
+			if err != nil {
+				return fmt.Errorf("failed to decode key %v: %w", iter.Key().Interface(), err)
+			}
+
+			elem := reflect.New(v.Type().Elem()).Elem()
+			err = decodeValue(elem, iter.Value().Interface(), o)
+			if err != nil {
+				return fmt.Errorf("failed to decode key %v: %w", iter.Key().Interface(), err)
+			}
+
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		// Copy bytes into byte slices (e.g. binary data of CBOR) and decode base64 strings like encoding/json does.
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			switch b := data.(type) {
+			case []byte:
+				v.SetBytes(bytes.Clone(b))
+				return nil
+			case string:
+				decoded, err := base64.StdEncoding.DecodeString(b)
+				if err != nil {
+					return fmt.Errorf("failed to decode base64: %w", err)
+				}
+
+				v.SetBytes(decoded)
+				return nil
+			}
+		}
+
+		if d.Kind() != reflect.Slice && d.Kind() != reflect.Array {
+			return fmt.Errorf("cannot decode %s into %s", d.Kind(), v.Kind())
+		}
+
+		// Replace slices, but keep the length of arrays.
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), d.Len(), d.Len()))
+		}
+
+		for i := 0; i < min(v.Len(), d.Len()); i++ {
+			err := decodeValue(v.Index(i), d.Index(i).Interface(), o)
+			if err != nil {
+				return fmt.Errorf("failed to decode index %d: %w", i, err)
+			}
+		}
+	default:
+		return setValue(v, data)
+	}
+
+	return nil
+}
+
+// Checks whether the value implements one of the unmarshaler interfaces of YAML.
+func isYAMLUnmarshaler(v any) bool {
+	switch v.(type) {
+	case yaml.BytesUnmarshaler, yaml.BytesUnmarshalerContext,
+		yaml.InterfaceUnmarshaler, yaml.InterfaceUnmarshalerContext,
+		yaml.NodeUnmarshaler, yaml.NodeUnmarshalerContext:
+		return true
+	}
+
+	return false
+}
+
+// Converts the numbers of the generic data (e.g. json.Number decoded from JSON) to float64 like encoding/json does.
+func interfaceData(data any) any {
+	return convertNumbers(data, func(n json.Number) any {
+		f, err := n.Float64()
+		if err != nil {
+			return n.String()
+		}
+
+		return f
+	})
+}
+
+// Converts the numbers of the generic data to int64 or float64 (keeps integers encoded as integers).
+func plainData(data any) any {
+	return convertNumbers(data, func(n json.Number) any {
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+
+		f, err := n.Float64()
+		if err != nil {
+			return n.String()
+		}
+
+		return f
+	})
+}
+
+// Converts the json.Number values of the generic data by the given function.
+func convertNumbers(data any, convert func(n json.Number) any) any {
+	switch d := data.(type) {
+	case json.Number:
+		return convert(d)
+	case []any:
+		values := make([]any, len(d))
+		for i, v := range d {
+			values[i] = convertNumbers(v, convert)
+		}
+
+		return values
+	case map[string]any:
+		values := make(map[string]any, len(d))
+		for k, v := range d {
+			values[k] = convertNumbers(v, convert)
+		}
+
+		return values
+	}
+
+	return data
+}
@@ -2,8 +2,13 @@ package dotpath
 
 import (
 	"encoding/json"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
 )
 
 // CustomUnmarshaler is a custom type that implements json.Unmarshaler for testing
@@ -20,6 +25,20 @@ func (c *CustomUnmarshaler) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UpperUnmarshaler is a custom type that implements yaml.BytesUnmarshaler for testing
+type UpperUnmarshaler struct {
+	Value string
+}
+
+func (u *UpperUnmarshaler) UnmarshalYAML(data []byte) error {
+	var s string
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	u.Value = strings.ToUpper(s)
+	return nil
+}
+
 func Test_structField(t *testing.T) {
 	type TestStruct struct {
 		Name     string
@@ -28,10 +47,55 @@ func Test_structField(t *testing.T) {
 		IsActive bool   `json:"isActive,omitempty"`
 	}
 
+	type Common struct {
+		LogLevel string `json:"log_level"`
+		Name     string
+	}
+
+	type Other struct {
+		Name  string
+		Level string
+	}
+
+	type EmbeddingStruct struct {
+		Common
+		*Other
+		Name  string
+		Inner struct {
+			Port int
+		} `confless:"inline"`
+		Named Common `json:"named"`
+	}
+
+	type ConflictStruct struct {
+		Common
+		Other
+	}
+
+	type IgnoredStruct struct {
+		Internal string `confless:"-"`
+		Secret   string `json:"-"`
+		Hidden   string `yaml:"-"`
+		Dash     string `json:"-,"`
+	}
+
+	type YAMLInlineStruct struct {
+		Inner struct {
+			Port int
+		} `yaml:",inline"`
+	}
+
+	type CustomTagStruct struct {
+		Host    string `toml:"hostname" mapstructure:"host_name"`
+		Port    int    `json:"port"`
+		Ignored string `toml:"-"`
+	}
+
 	tests := []struct {
 		name     string
 		s        reflect.Value
 		n        string
+		opts     []Option
 		wantErr  bool
 		validate func(t *testing.T, got reflect.Value)
 	}{
@@ -95,11 +159,201 @@ func Test_structField(t *testing.T) {
 			n:       "anything",
 			wantErr: true,
 		},
+		{
+			name: "find promoted field of embedded struct",
+			s: reflect.ValueOf(EmbeddingStruct{
+				Common: Common{LogLevel: "debug"},
+			}),
+			n: "log_level",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "debug" {
+					t.Errorf("got %v, want debug", got.String())
+				}
+			},
+		},
+		{
+			name: "shallower field wins over promoted field",
+			s: reflect.ValueOf(EmbeddingStruct{
+				Common: Common{Name: "embedded"},
+				Name:   "outer",
+			}),
+			n: "name",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "outer" {
+					t.Errorf("got %v, want outer", got.String())
+				}
+			},
+		},
+		{
+			name: "find promoted field of embedded pointer",
+			s: reflect.ValueOf(EmbeddingStruct{
+				Other: &Other{Level: "high"},
+			}),
+			n: "level",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "high" {
+					t.Errorf("got %v, want high", got.String())
+				}
+			},
+		},
+		{
+			name:    "nil embedded pointer is not settable",
+			s:       reflect.ValueOf(EmbeddingStruct{}),
+			n:       "level",
+			wantErr: true,
+		},
+		{
+			name: "named embedded struct is not flattened",
+			s:    reflect.ValueOf(EmbeddingStruct{}),
+			n:    "named",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.Type() != reflect.TypeOf(Common{}) {
+					t.Errorf("got %v, want Common", got.Type())
+				}
+			},
+		},
+		{
+			name: "find field of inline struct",
+			s: reflect.ValueOf(EmbeddingStruct{
+				Inner: struct {
+					Port int
+				}{Port: 8080},
+			}),
+			n: "port",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.Int() != 8080 {
+					t.Errorf("got %v, want 8080", got.Int())
+				}
+			},
+		},
+		{
+			name:    "conflicting promoted fields are dropped",
+			s:       reflect.ValueOf(ConflictStruct{}),
+			n:       "name",
+			wantErr: true,
+		},
+		{
+			name:    "ignore field with confless tag",
+			s:       reflect.ValueOf(IgnoredStruct{}),
+			n:       "internal",
+			wantErr: true,
+		},
+		{
+			name:    "ignore field with json tag",
+			s:       reflect.ValueOf(IgnoredStruct{}),
+			n:       "secret",
+			wantErr: true,
+		},
+		{
+			name:    "ignore field with yaml tag",
+			s:       reflect.ValueOf(IgnoredStruct{}),
+			n:       "hidden",
+			wantErr: true,
+		},
+		{
+			name: "find field named dash",
+			s: reflect.ValueOf(IgnoredStruct{
+				Dash: "dash",
+			}),
+			n: "-",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "dash" {
+					t.Errorf("got %v, want dash", got.String())
+				}
+			},
+		},
+		{
+			name: "find field by custom tag",
+			s: reflect.ValueOf(CustomTagStruct{
+				Host: "localhost",
+			}),
+			n:    "host_name",
+			opts: []Option{WithTags("toml", "mapstructure")},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "localhost" {
+					t.Errorf("got %v, want localhost", got.String())
+				}
+			},
+		},
+		{
+			name:    "ignore tags that are not configured",
+			s:       reflect.ValueOf(CustomTagStruct{}),
+			n:       "port",
+			opts:    []Option{WithTags("toml"), WithFieldNames(false)},
+			wantErr: true,
+		},
+		{
+			name: "keep field with dash in custom tag",
+			s: reflect.ValueOf(CustomTagStruct{
+				Ignored: "kept",
+			}),
+			n:    "ignored",
+			opts: []Option{WithTags("toml")},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "kept" {
+					t.Errorf("got %v, want kept", got.String())
+				}
+			},
+		},
+		{
+			name:    "ignore struct field name if disabled",
+			s:       reflect.ValueOf(CustomTagStruct{}),
+			n:       "host",
+			opts:    []Option{WithTags("toml"), WithFieldNames(false)},
+			wantErr: true,
+		},
+		{
+			name:    "exact matching rejects different case",
+			s:       reflect.ValueOf(TestStruct{}),
+			n:       "name",
+			opts:    []Option{WithMatching(MatchExact)},
+			wantErr: true,
+		},
+		{
+			name: "exact matching finds same case",
+			s: reflect.ValueOf(TestStruct{
+				Email: "test@example.com",
+			}),
+			n:    "email_address",
+			opts: []Option{WithMatching(MatchExact)},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "test@example.com" {
+					t.Errorf("got %v, want test@example.com", got.String())
+				}
+			},
+		},
+		{
+			name: "normalized matching ignores separators",
+			s: reflect.ValueOf(TestStruct{
+				IsActive: true,
+			}),
+			n:    "is-active",
+			opts: []Option{WithMatching(MatchNormalized)},
+			validate: func(t *testing.T, got reflect.Value) {
+				if !got.Bool() {
+					t.Errorf("got %v, want true", got.Bool())
+				}
+			},
+		},
+		{
+			name: "find field of yaml inline struct",
+			s: reflect.ValueOf(YAMLInlineStruct{
+				Inner: struct {
+					Port int
+				}{Port: 8080},
+			}),
+			n: "port",
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.Int() != 8080 {
+					t.Errorf("got %v, want 8080", got.Int())
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := structField(tt.s, tt.n)
+			got, err := structField(tt.s, tt.n, accessAlloc, newOptions(tt.opts...))
 			if err != nil {
 				if !tt.wantErr {
 					t.Errorf("structField() failed: %v", err)
@@ -229,6 +483,18 @@ func Test_getValue(t *testing.T) {
 			p:       "PtrNested.Value",
 			wantErr: true,
 		},
+		{
+			name:    "settable nil pointer is not allocated",
+			v:       reflect.ValueOf(&TestStruct{}),
+			p:       "PtrNested.Value",
+			wantErr: true,
+		},
+		{
+			name:    "settable slice is not extended",
+			v:       reflect.ValueOf(&TestStruct{Items2: []Nested{{Value: "a"}}}),
+			p:       "Items2.1.Value",
+			wantErr: true,
+		},
 		{
 			name:    "invalid field",
 			v:       reflect.ValueOf(TestStruct{}),
@@ -293,7 +559,7 @@ func Test_getValue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getValue(tt.v, tt.p)
+			got, err := getValue(tt.v, tt.p, accessRead, newOptions())
 			if err != nil {
 				if !tt.wantErr {
 					t.Errorf("getValue() failed: %v", err)
@@ -310,6 +576,224 @@ func Test_getValue(t *testing.T) {
 	}
 }
 
+func Test_getValueByParts(t *testing.T) {
+	type DB struct {
+		MaxConns int `json:"max_conns"`
+		Max      struct {
+			Idle int
+		}
+	}
+
+	type TestStruct struct {
+		DB     DB     `json:"db"`
+		DBHost string `json:"db_host"`
+		Items  []DB
+	}
+
+	tests := []struct {
+		name     string
+		v        reflect.Value
+		parts    []string
+		wantErr  bool
+		validate func(t *testing.T, got reflect.Value)
+	}{
+		{
+			name: "match longest name",
+			v: reflect.ValueOf(TestStruct{
+				DB: DB{MaxConns: 10},
+			}),
+			parts: []string{"db", "max", "conns"},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.Int() != 10 {
+					t.Errorf("got %v, want 10", got.Int())
+				}
+			},
+		},
+		{
+			name: "match shorter name",
+			v: reflect.ValueOf(TestStruct{
+				DB: DB{Max: struct{ Idle int }{Idle: 5}},
+			}),
+			parts: []string{"db", "max", "idle"},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.Int() != 5 {
+					t.Errorf("got %v, want 5", got.Int())
+				}
+			},
+		},
+		{
+			name: "prefer name with separator on the same level",
+			v: reflect.ValueOf(TestStruct{
+				DBHost: "localhost",
+			}),
+			parts: []string{"db", "host"},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.String() != "localhost" {
+					t.Errorf("got %v, want localhost", got.String())
+				}
+			},
+		},
+		{
+			name: "resolve slice index",
+			v: reflect.ValueOf(TestStruct{
+				Items: []DB{{MaxConns: 1}, {MaxConns: 2}},
+			}),
+			parts: []string{"items", "1", "max", "conns"},
+			validate: func(t *testing.T, got reflect.Value) {
+				if got.Int() != 2 {
+					t.Errorf("got %v, want 2", got.Int())
+				}
+			},
+		},
+		{
+			name:    "field not found",
+			v:       reflect.ValueOf(TestStruct{}),
+			parts:   []string{"db", "min", "conns"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := getValueByParts(tt.v, tt.parts, "_", accessRead, newOptions())
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("getValueByParts() failed: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("getValueByParts() succeeded unexpectedly")
+			}
+			if tt.validate != nil {
+				tt.validate(t, got)
+			}
+		})
+	}
+}
+
+func Test_resolveValue(t *testing.T) {
+	type Nested struct {
+		Value string
+	}
+
+	type TestStruct struct {
+		PtrNested *Nested
+		Items     []int
+		Items2    []Nested
+	}
+
+	tests := []struct {
+		name     string
+		obj      *TestStruct
+		p        string
+		wantErr  bool
+		validate func(t *testing.T, obj *TestStruct, got reflect.Value)
+	}{
+		{
+			name: "allocate nil pointer",
+			obj:  &TestStruct{},
+			p:    "PtrNested.Value",
+			validate: func(t *testing.T, obj *TestStruct, got reflect.Value) {
+				if obj.PtrNested == nil || !got.CanSet() {
+					t.Errorf("got %v, want allocated settable value", obj.PtrNested)
+				}
+			},
+		},
+		{
+			name: "extend slice by index equal to length",
+			obj:  &TestStruct{Items2: []Nested{{Value: "a"}}},
+			p:    "Items2.1.Value",
+			validate: func(t *testing.T, obj *TestStruct, got reflect.Value) {
+				if len(obj.Items2) != 2 || !got.CanSet() {
+					t.Errorf("got %v, want 2 elements", obj.Items2)
+				}
+			},
+		},
+		{
+			name:    "index beyond length",
+			obj:     &TestStruct{},
+			p:       "Items.1",
+			wantErr: true,
+		},
+		{
+			name:    "no allocation if the path does not resolve",
+			obj:     &TestStruct{},
+			p:       "PtrNested.Unknown",
+			wantErr: true,
+			validate: func(t *testing.T, obj *TestStruct, _ reflect.Value) {
+				if obj.PtrNested != nil {
+					t.Errorf("got %v, want nil", obj.PtrNested)
+				}
+			},
+		},
+		{
+			name:    "no element appended if the path does not resolve",
+			obj:     &TestStruct{Items2: []Nested{{Value: "a"}}},
+			p:       "Items2.1.Unknown",
+			wantErr: true,
+			validate: func(t *testing.T, obj *TestStruct, _ reflect.Value) {
+				if len(obj.Items2) != 1 {
+					t.Errorf("got %v, want 1 element", obj.Items2)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveValue(reflect.ValueOf(tt.obj), tt.p, newOptions())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.validate != nil {
+				tt.validate(t, tt.obj, got)
+			}
+		})
+	}
+}
+
+func Test_resolveValueByParts(t *testing.T) {
+	type Pool struct {
+		Count int
+	}
+
+	type DB struct {
+		MaxPool *Pool `json:"max_pool"`
+		Max     *struct {
+			PoolSize int `json:"pool_size"`
+		}
+	}
+
+	type TestStruct struct {
+		DB *DB `json:"db"`
+	}
+
+	// "max_pool" is tried first, but "size" cannot be resolved below it: backtracking must not leave it allocated.
+	obj := &TestStruct{}
+	got, err := resolveValueByParts(reflect.ValueOf(obj), []string{"db", "max", "pool", "size"}, "_", newOptions())
+	if err != nil {
+		t.Fatalf("resolveValueByParts() failed: %v", err)
+	}
+	got.SetInt(5)
+	if obj.DB == nil || obj.DB.Max == nil || obj.DB.Max.PoolSize != 5 {
+		t.Fatalf("got %+v, want db.max.pool_size to be 5", obj.DB)
+	}
+	if obj.DB.MaxPool != nil {
+		t.Errorf("got %+v, want db.max_pool not to be allocated", obj.DB.MaxPool)
+	}
+
+	// Unresolved parts leave the object unchanged.
+	obj = &TestStruct{}
+	_, err = resolveValueByParts(reflect.ValueOf(obj), []string{"db", "min", "size"}, "_", newOptions())
+	if err == nil {
+		t.Fatal("resolveValueByParts() succeeded unexpectedly")
+	}
+	if obj.DB != nil {
+		t.Errorf("got %+v, want db not to be allocated", obj.DB)
+	}
+}
+
 func Test_setValue(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -443,6 +927,58 @@ func Test_setValue(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "set string from bytes",
+			v:     reflect.ValueOf(new(string)).Elem(),
+			value: []byte("test"),
+			validate: func(t *testing.T, v reflect.Value) {
+				if v.String() != "test" {
+					t.Errorf("got %v, want test", v.String())
+				}
+			},
+		},
+		{
+			name:  "set duration from string",
+			v:     reflect.ValueOf(new(time.Duration)).Elem(),
+			value: "1m30s",
+			validate: func(t *testing.T, v reflect.Value) {
+				if d := time.Duration(v.Int()); d != 90*time.Second {
+					t.Errorf("got %v, want 1m30s", d)
+				}
+			},
+		},
+		{
+			name:  "set duration from number",
+			v:     reflect.ValueOf(new(time.Duration)).Elem(),
+			value: 1000,
+			validate: func(t *testing.T, v reflect.Value) {
+				if d := time.Duration(v.Int()); d != time.Microsecond {
+					t.Errorf("got %v, want 1µs", d)
+				}
+			},
+		},
+		{
+			name:    "set duration (invalid)",
+			v:       reflect.ValueOf(new(time.Duration)).Elem(),
+			value:   "5 seconds",
+			wantErr: true,
+		},
+		{
+			name:  "set encoding.TextUnmarshaler",
+			v:     reflect.ValueOf(new(net.IP)).Elem(),
+			value: "10.0.0.1",
+			validate: func(t *testing.T, v reflect.Value) {
+				if ip := v.Interface().(net.IP); !ip.Equal(net.IPv4(10, 0, 0, 1)) {
+					t.Errorf("got %v, want 10.0.0.1", ip)
+				}
+			},
+		},
+		{
+			name:    "set encoding.TextUnmarshaler (invalid)",
+			v:       reflect.ValueOf(new(net.IP)).Elem(),
+			value:   "invalid",
+			wantErr: true,
+		},
 		{
 			name:    "unsettable value",
 			v:       reflect.ValueOf("not settable"),
@@ -469,3 +1005,197 @@ func Test_setValue(t *testing.T) {
 		})
 	}
 }
+
+func Test_decodeValue(t *testing.T) {
+	type Common struct {
+		LogLevel string `json:"log_level"`
+	}
+
+	type TestStruct struct {
+		*Common
+		Name     string
+		Port     int
+		Tags     []string
+		Labels   map[string]int
+		Extra    any
+		Nested   *struct{ Value float64 }
+		Custom   CustomUnmarshaler
+		Upper    UpperUnmarshaler
+		Meta     map[string]any
+		IP       net.IP
+		Delay    time.Duration
+		Started  time.Time
+		Data     []byte
+		Settings struct {
+			Timeout int
+		} `confless:"inline"`
+	}
+
+	tests := []struct {
+		name     string
+		data     any
+		wantErr  bool
+		validate func(t *testing.T, got *TestStruct)
+	}{
+		{
+			name: "decode basic values",
+			data: map[string]any{"name": "app", "PORT": json.Number("8080")},
+			validate: func(t *testing.T, got *TestStruct) {
+				if got.Name != "app" || got.Port != 8080 {
+					t.Errorf("got %v/%v, want app/8080", got.Name, got.Port)
+				}
+			},
+		},
+		{
+			name: "decode promoted and inlined fields",
+			data: map[string]any{"log_level": "debug", "timeout": 30},
+			validate: func(t *testing.T, got *TestStruct) {
+				if got.Common == nil || got.LogLevel != "debug" {
+					t.Errorf("got %v, want debug", got.Common)
+				}
+				if got.Settings.Timeout != 30 {
+					t.Errorf("got %v, want 30", got.Settings.Timeout)
+				}
+			},
+		},
+		{
+			name: "decode collections",
+			data: map[string]any{
+				"tags":   []any{"a", "b"},
+				"labels": map[string]any{"x": "1"},
+				"extra":  map[string]any{"k": "v"},
+				"nested": map[string]any{"value": 1.5},
+			},
+			validate: func(t *testing.T, got *TestStruct) {
+				if !reflect.DeepEqual(got.Tags, []string{"a", "b"}) {
+					t.Errorf("got %v, want [a b]", got.Tags)
+				}
+				if got.Labels["x"] != 1 {
+					t.Errorf("got %v, want map[x:1]", got.Labels)
+				}
+				if !reflect.DeepEqual(got.Extra, map[string]any{"k": "v"}) {
+					t.Errorf("got %v, want map[k:v]", got.Extra)
+				}
+				if got.Nested == nil || got.Nested.Value != 1.5 {
+					t.Errorf("got %v, want 1.5", got.Nested)
+				}
+			},
+		},
+		{
+			name: "decode json.Unmarshaler",
+			data: map[string]any{"custom": "test"},
+			validate: func(t *testing.T, got *TestStruct) {
+				if got.Custom.Value != "unmarshaled:test" {
+					t.Errorf("got %v, want unmarshaled:test", got.Custom.Value)
+				}
+			},
+		},
+		{
+			name: "decode encoding.TextUnmarshaler, durations and times",
+			data: map[string]any{"ip": "10.0.0.1", "delay": "5s", "started": "2024-01-02T03:04:05Z"},
+			validate: func(t *testing.T, got *TestStruct) {
+				if !got.IP.Equal(net.IPv4(10, 0, 0, 1)) {
+					t.Errorf("got %v, want 10.0.0.1", got.IP)
+				}
+				if got.Delay != 5*time.Second {
+					t.Errorf("got %v, want 5s", got.Delay)
+				}
+				if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !got.Started.Equal(want) {
+					t.Errorf("got %v, want %v", got.Started, want)
+				}
+			},
+		},
+		{
+			name: "decode bytes",
+			data: map[string]any{"data": []byte{0x00, 0xff}, "name": []byte("app")},
+			validate: func(t *testing.T, got *TestStruct) {
+				if !reflect.DeepEqual(got.Data, []byte{0x00, 0xff}) {
+					t.Errorf("got %v, want [0 255]", got.Data)
+				}
+				if got.Name != "app" {
+					t.Errorf("got %v, want app", got.Name)
+				}
+			},
+		},
+		{
+			name: "decode YAML unmarshaler",
+			data: map[string]any{"upper": "hello"},
+			validate: func(t *testing.T, got *TestStruct) {
+				if got.Upper.Value != "HELLO" {
+					t.Errorf("got %v, want HELLO", got.Upper.Value)
+				}
+			},
+		},
+		{
+			name: "decode YAML unmarshaler from number",
+			data: map[string]any{"upper": json.Number("8080")},
+			validate: func(t *testing.T, got *TestStruct) {
+				if got.Upper.Value != "8080" {
+					t.Errorf("got %v, want 8080", got.Upper.Value)
+				}
+			},
+		},
+		{
+			name: "decode base64 strings into bytes",
+			data: map[string]any{"data": "aGVsbG8="},
+			validate: func(t *testing.T, got *TestStruct) {
+				if string(got.Data) != "hello" {
+					t.Errorf("got %q, want hello", got.Data)
+				}
+			},
+		},
+		{
+			name: "decode numbers into interfaces as float64",
+			data: map[string]any{
+				"extra": []any{json.Number("1"), map[string]any{"n": json.Number("2.5")}},
+				"meta":  map[string]any{"port": json.Number("8080")},
+			},
+			validate: func(t *testing.T, got *TestStruct) {
+				if want := []any{float64(1), map[string]any{"n": 2.5}}; !reflect.DeepEqual(got.Extra, want) {
+					t.Errorf("got %#v, want %#v", got.Extra, want)
+				}
+				if want := map[string]any{"port": float64(8080)}; !reflect.DeepEqual(got.Meta, want) {
+					t.Errorf("got %#v, want %#v", got.Meta, want)
+				}
+			},
+		},
+		{
+			name: "ignore unknown keys",
+			data: map[string]any{"unknown": "value"},
+		},
+		{
+			name:    "error for invalid base64",
+			data:    map[string]any{"data": "not base64"},
+			wantErr: true,
+		},
+		{
+			name:    "error for mismatching type",
+			data:    map[string]any{"tags": "a"},
+			wantErr: true,
+		},
+		{
+			name:    "error for invalid value",
+			data:    map[string]any{"port": "invalid"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := &TestStruct{}
+			err := decodeValue(reflect.ValueOf(got).Elem(), tt.data, newOptions())
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("decodeValue() failed: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("decodeValue() succeeded unexpectedly")
+			}
+			if tt.validate != nil {
+				tt.validate(t, got)
+			}
+		})
+	}
+}
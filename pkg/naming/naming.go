@@ -0,0 +1,71 @@
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Strategy to convert a field name into the name used by a source.
+type Strategy func(name string) string
+
+// Splits the name into lower-case words.
+// Words are separated by dashes, underscores, dots, spaces and changes of case
+// (e.g. "MaxIdleConns" -> ["max", "idle", "conns"], "HTTPServer" -> ["http", "server"]).
+func Words(name string) []string {
+	words := make([]string, 0)
+	runes := []rune(name)
+
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == '_' || r == '.' || unicode.IsSpace(r):
+			// Separators end the current word.
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			next := rune(0)
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+
+			// A new word starts after a lower-case letter or digit (e.g. "maxIdle")
+			// or at the last upper-case letter of an acronym (e.g. "HTTPServer").
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && unicode.IsLower(next)) {
+				flush()
+			}
+		}
+
+		word = append(word, r)
+	}
+	flush()
+
+	return words
+}
+
+// Converts the name to kebab-case (e.g. "MaxIdleConns" -> "max-idle-conns").
+func Kebab(name string) string {
+	return strings.Join(Words(name), "-")
+}
+
+// Converts the name to snake_case (e.g. "MaxIdleConns" -> "max_idle_conns").
+func Snake(name string) string {
+	return strings.Join(Words(name), "_")
+}
+
+// Converts the name to SCREAMING_SNAKE_CASE (e.g. "MaxIdleConns" -> "MAX_IDLE_CONNS").
+func ScreamingSnake(name string) string {
+	return strings.ToUpper(Snake(name))
+}
+
+// Converts the name to lowercase without separators (e.g. "MaxIdleConns" -> "maxidleconns").
+func Lower(name string) string {
+	return strings.Join(Words(name), "")
+}
@@ -0,0 +1,97 @@
+package naming
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{
+			name: "camel case",
+			in:   "maxIdleConns",
+			want: []string{"max", "idle", "conns"},
+		},
+		{
+			name: "pascal case",
+			in:   "MaxIdleConns",
+			want: []string{"max", "idle", "conns"},
+		},
+		{
+			name: "acronyms",
+			in:   "HTTPServerURL",
+			want: []string{"http", "server", "url"},
+		},
+		{
+			name: "digits",
+			in:   "Port8080Alt",
+			want: []string{"port8080", "alt"},
+		},
+		{
+			name: "separators",
+			in:   "max_idle-conns.total",
+			want: []string{"max", "idle", "conns", "total"},
+		},
+		{
+			name: "screaming snake case",
+			in:   "MAX_IDLE_CONNS",
+			want: []string{"max", "idle", "conns"},
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Words(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Words() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     string
+	}{
+		{
+			name:     "kebab case",
+			strategy: Kebab,
+			want:     "max-idle-conns",
+		},
+		{
+			name:     "snake case",
+			strategy: Snake,
+			want:     "max_idle_conns",
+		},
+		{
+			name:     "screaming snake case",
+			strategy: ScreamingSnake,
+			want:     "MAX_IDLE_CONNS",
+		},
+		{
+			name:     "lowercase",
+			strategy: Lower,
+			want:     "maxidleconns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.strategy("MaxIdleConns")
+			if got != tt.want {
+				t.Errorf("strategy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package reflectutil
 
 import (
 	"reflect"
+	"strings"
 )
 
 // Returns a pointer to the given value.
@@ -47,3 +48,38 @@ func UnpackValue(v reflect.Value) reflect.Value {
 
 	return v
 }
+
+// Parses the tag with the given key into a map of key-value pairs.
+// For example, the tag `confless:"file,format=yaml"` with the key "confless" will be parsed into:
+//
+//	{
+//	  "file": "true",
+//	  "format": "yaml",
+//	}
+func ParseTag(t reflect.StructTag, key string) map[string]string {
+	tag := t.Get(key)
+
+	kvs := make(map[string]string)
+	if tag == "" {
+		return kvs
+	}
+
+	// Iterate over the parts of the tag separated by commas.
+	for part := range strings.SplitSeq(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// Split the part into key and value.
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) < 2 {
+			// If no value is provided, use "true" as the value.
+			kv = append(kv, "true")
+		}
+
+		kvs[kv[0]] = kv[1]
+	}
+
+	return kvs
+}
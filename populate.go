@@ -1,16 +1,19 @@
 package confless
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 
 	"dario.cat/mergo"
-	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
 
 	"github.com/codetent/confless/pkg/dotpath"
 	"github.com/codetent/confless/pkg/reflectutil"
@@ -19,42 +22,138 @@ import (
 var (
 	ErrInvalidObject    = errors.New("invalid object")
 	ErrDecodeFileFailed = errors.New("failed to decode file")
+	ErrAmbiguousName    = dotpath.ErrAmbiguousName
+	ErrConflictingEnv   = errors.New("conflicting environment variables")
+	ErrUnknownFormat    = errors.New("unknown file format")
 )
 
-// Populate the object by the given flags.
+// Suffix of environment variables referencing a file to read the value from.
+const envFileSuffix = "_FILE"
+
+// Populate the object by the flags of the given source.
 // Names are converted to dot-separated paths (e.g. "my-flag" -> "my.flag").
-func populateByFlags(fset *flag.FlagSet, obj any) error {
+// If a naming strategy is set, names are matched against the converted field names instead.
+// Fields can declare their own flag names by tags (e.g. `confless:"flag=db-url,short=d"`).
+func populateByFlags(src *flagSource, obj any, opts ...dotpath.Option) error {
 	// Check if the object is a pointer.
 	if reflect.TypeOf(obj).Kind() != reflect.Pointer {
 		return fmt.Errorf("%w: object is not a pointer", ErrInvalidObject)
 	}
 
-	fset.Visit(func(f *flag.Flag) {
+	// Rename subtrees by the prefix tags.
+	opts = append(slices.Clone(opts), dotpath.WithPrefixes(true))
+
+	// Collect the fields with declared flag names.
+	named := make(map[string]reflect.Value)
+	for field, names := range findNamedFields(obj, []string{"flag", "short"}, opts...) {
+		for _, name := range names {
+			named[name] = field
+		}
+	}
+
+	src.set.Visit(func(f *flag.Flag) {
+		// Set the field with the declared name (ignore errors).
+		if field, ok := named[f.Name]; ok {
+			_ = dotpath.SetValue(field, f.Value.String())
+			return
+		}
+
+		// Match the parts against the converted field names (ignore errors).
+		if src.naming != nil {
+			parts := strings.Split(f.Name, "-")
+			_ = dotpath.SetParts(obj, parts, "-", f.Value.String(), append(opts, dotpath.WithNaming(src.naming))...)
+			return
+		}
+
 		// Replace the dash in the key with a dot.
 		key := strings.ReplaceAll(f.Name, "-", ".")
 
 		// Set the value at the given path (ignore errors).
-		_ = dotpath.Set(obj, key, f.Value.String())
+		_ = dotpath.Set(obj, key, f.Value.String(), opts...)
 	})
 
 	return nil
 }
 
-// Populate the object by environment variables with the given prefix.
+// Populate the object by environment variables with the prefix of the given source.
 // Overrides existing values only if set in the environment variables.
-// Names are converted to dot-separated paths (e.g. "MY_FLAG" -> "my.flag").
-func populateByEnv(envs []string, pre string, obj any) error {
-	// If the prefix is empty, do nothing.
-	if pre == "" {
-		return nil
+// Names are converted to paths according to the nesting of the source (e.g. "MY_FLAG" -> "my.flag").
+// Fields can declare their own names by tags (e.g. `confless:"env=DATABASE_URL|PGURL"`),
+// which are looked up with and without the prefix (first match wins).
+// If enabled, values of variables with the "_FILE" suffix are read from the referenced file.
+// Returns the names of the consumed variables to scrub according to the source.
+func populateByEnv(fs afero.Fs, envs []string, src *envSource, obj any, opts ...dotpath.Option) ([]string, error) {
+	// If the prefix is empty, do nothing unless enabled explicitly.
+	if src.prefix == "" && !src.unprefixed {
+		return nil, nil
 	}
 
 	// Check if the object is a pointer.
 	if reflect.TypeOf(obj).Kind() != reflect.Pointer {
-		return fmt.Errorf("%w: object is not a pointer", ErrInvalidObject)
+		return nil, fmt.Errorf("%w: object is not a pointer", ErrInvalidObject)
+	}
+
+	// Rename subtrees by the prefix tags.
+	opts = append(slices.Clone(opts), dotpath.WithPrefixes(true))
+
+	// Collect the names of consumed variables to scrub.
+	scrub := make([]string, 0)
+	secrets := make(map[fieldKey]bool)
+	if src.scrubbing == EnvScrubSecrets {
+		for field := range findSecretFields(obj, opts...) {
+			secrets[keyOfField(field)] = true
+		}
 	}
 
-	prefix := strings.ToLower(pre) + "_"
+	consume := func(name string, field reflect.Value) {
+		if src.scrubbing == EnvScrubConsumed || (src.scrubbing == EnvScrubSecrets && secrets[keyOfField(field)]) {
+			scrub = append(scrub, name)
+		}
+	}
+
+	prefix := ""
+	if src.prefix != "" {
+		prefix = src.prefix + "_"
+	}
+
+	// Index the environment variables by their upper-case name.
+	values := make(map[string]string)
+	names := make(map[string]string)
+	for _, env := range envs {
+		parts := strings.SplitN(env, "=", 2)
+		if len(parts) == 2 {
+			values[strings.ToUpper(parts[0])] = parts[1]
+			names[strings.ToUpper(parts[0])] = parts[0]
+		}
+	}
+
+	// Collect the declared names of fields.
+	type namedField struct {
+		field reflect.Value
+		names []string
+	}
+
+	named := make([]namedField, 0)
+	declared := make(map[string]bool)
+	for field, names := range findNamedFields(obj, []string{"env"}, opts...) {
+		candidates := make([]string, 0, 2*len(names))
+		for _, name := range names {
+			if prefix != "" {
+				candidates = append(candidates, strings.ToUpper(prefix+name))
+			}
+
+			candidates = append(candidates, strings.ToUpper(name))
+		}
+
+		for _, name := range candidates {
+			declared[name] = true
+			if src.files {
+				declared[name+envFileSuffix] = true
+			}
+		}
+
+		named = append(named, namedField{field: field, names: candidates})
+	}
 
 	for _, env := range envs {
 		// Split the environment variable into key and value.
@@ -63,57 +162,264 @@ func populateByEnv(envs []string, pre string, obj any) error {
 			continue
 		}
 
-		// Remove the prefix from the key.
-		key := strings.ToLower(parts[0])
-		key, ok := strings.CutPrefix(key, prefix)
-		if !ok {
+		// Skip variables with declared names (set below).
+		if declared[strings.ToUpper(parts[0])] {
 			continue
 		}
 
-		// Replace the underscore in the key with a dot.
-		path := strings.ReplaceAll(key, "_", ".")
+		// Without a prefix, only declared names are mapped.
+		if prefix == "" {
+			continue
+		}
+
+		// Remove the prefix from the key (case-insensitive).
+		// The case of the key is kept to be matched according to the policy.
+		key := parts[0]
+		if len(key) < len(prefix) || !strings.EqualFold(key[:len(prefix)], prefix) {
+			continue
+		}
+		key = key[len(prefix):]
+
+		// Read the value from the referenced file if the key without suffix matches a field.
+		if src.files && len(key) > len(envFileSuffix) && strings.EqualFold(key[len(key)-len(envFileSuffix):], envFileSuffix) {
+			name := parts[0][:len(parts[0])-len(envFileSuffix)]
+			field, err := lookupEnvPath(obj, key[:len(key)-len(envFileSuffix)], src, opts...)
+			if err == nil {
+				// The value of the variable itself must not be set as well.
+				if _, ok := values[strings.ToUpper(name)]; ok {
+					return nil, fmt.Errorf("%w: both %s and %s are set", ErrConflictingEnv, name, parts[0])
+				}
+
+				err = setFileContent(fs, field, parts[0], parts[1])
+				if err != nil {
+					return nil, err
+				}
 
-		// Set the value at the given path.
-		err := dotpath.Set(obj, path, parts[1])
+				consume(parts[0], field)
+				continue
+			}
+		}
+
+		// Get the field at the path given by the key.
+		field, err := lookupEnvPath(obj, key, src, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", parts[0], err)
+		}
+
+		err = setNamedValue(field, parts[0], parts[1])
 		if err != nil {
-			return fmt.Errorf("failed to set path %s to %s: %w", path, parts[1], err)
+			return nil, err
+		}
+
+		consume(parts[0], field)
+	}
+
+	// Set the fields with declared names (first match wins).
+	for _, n := range named {
+		for _, name := range n.names {
+			if value, ok := values[name]; ok {
+				err := setNamedValue(n.field, names[name], value)
+				if err != nil {
+					return nil, err
+				}
+
+				consume(names[name], n.field)
+				break
+			}
+
+			if path, ok := values[name+envFileSuffix]; ok && src.files {
+				err := setFileContent(fs, n.field, name+envFileSuffix, path)
+				if err != nil {
+					return nil, err
+				}
+
+				consume(names[name+envFileSuffix], n.field)
+				break
+			}
 		}
 	}
 
+	return scrub, nil
+}
+
+// Identifies a field by its address and type
+// (the first field of a struct shares the address of the struct).
+type fieldKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// Returns the key of the given field.
+func keyOfField(field reflect.Value) fieldKey {
+	if !field.CanAddr() {
+		return fieldKey{}
+	}
+
+	return fieldKey{addr: field.UnsafeAddr(), typ: field.Type()}
+}
+
+// Returns the field at the path given by the environment variable name (without prefix).
+func lookupEnvPath(obj any, key string, src *envSource, opts ...dotpath.Option) (reflect.Value, error) {
+	// Match the parts against the converted field names.
+	if src.naming != nil {
+		opts = append(opts, dotpath.WithNaming(src.naming))
+
+		sep := "_"
+		if src.nesting == EnvNestingDoubleUnderscore {
+			sep = "__"
+		}
+
+		return dotpath.ResolveParts(obj, strings.Split(key, sep), sep, opts...)
+	}
+
+	switch src.nesting {
+	case EnvNestingLongestMatch:
+		// Match the parts against the field names.
+		return dotpath.ResolveParts(obj, strings.Split(key, "_"), "_", opts...)
+	case EnvNestingDoubleUnderscore:
+		// Replace the double underscore in the key with a dot.
+		return dotpath.Resolve(obj, strings.ReplaceAll(key, "__", "."), opts...)
+	default:
+		// Replace the underscore in the key with a dot.
+		return dotpath.Resolve(obj, strings.ReplaceAll(key, "_", "."), opts...)
+	}
+}
+
+// Set the content of the file referenced by the given name (e.g. an environment variable) to the field.
+// Trailing newlines are trimmed. Errors never contain the content, as it is usually a secret.
+func setFileContent(fs afero.Fs, field reflect.Value, name string, path string) error {
+	content, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	err = dotpath.SetValue(field, strings.TrimRight(string(content), "\r\n"))
+	if err != nil {
+		return fmt.Errorf("failed to set %s: content of %s cannot be converted to %s", name, path, field.Type())
+	}
+
 	return nil
 }
 
-// Populate the object by a file with the given path and format.
-// Overrides existing values only if set in the file.
-func populateByFile(r io.Reader, format string, obj any) error {
+// Set the value to the field referenced by the given name (e.g. an environment variable).
+// Errors never contain the value, as it may be a secret.
+func setNamedValue(field reflect.Value, name string, value string) error {
+	err := dotpath.SetValue(field, value)
+	if err != nil {
+		return fmt.Errorf("failed to set %s: value cannot be converted to %s", name, field.Type())
+	}
+
+	return nil
+}
+
+// Populate the object by the files of the given directory (one file per key).
+// File names are converted to paths by the separator of the source (e.g. "database__host" -> "database.host").
+// Unknown keys, subdirectories and Kubernetes bookkeeping entries (e.g. "..data") are skipped.
+// A missing directory is skipped as well.
+func populateByDir(fs afero.Fs, src *dirSource, obj any, opts ...dotpath.Option) error {
 	// Check if the object is a pointer.
 	if reflect.TypeOf(obj).Kind() != reflect.Pointer {
 		return fmt.Errorf("%w: object is not a pointer", ErrInvalidObject)
 	}
 
-	// Create a new object of the same type as the given object.
-	decoded := reflectutil.MakeNewObject(reflect.TypeOf(obj))
+	entries, err := afero.ReadDir(fs, src.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		// Skip subdirectories and bookkeeping entries.
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+
+		// Replace the separator in the name with a dot.
+		key := entry.Name()
+		if src.separator != "" {
+			key = strings.ReplaceAll(key, src.separator, ".")
+		}
 
-	// Unmarshal the file based on the format.
-	switch format {
-	case "json":
-		err := json.NewDecoder(r).Decode(decoded)
+		// Skip unknown keys.
+		field, err := dotpath.Resolve(obj, key, opts...)
 		if err != nil {
-			return fmt.Errorf("%w: %w", ErrDecodeFileFailed, err)
+			continue
 		}
-	case "yaml":
-		err := yaml.NewDecoder(r).Decode(decoded)
+
+		err = setFileContent(fs, field, entry.Name(), filepath.Join(src.path, entry.Name()))
 		if err != nil {
-			return fmt.Errorf("%w: %w", ErrDecodeFileFailed, err)
+			return err
 		}
-	default:
-		return fmt.Errorf("unsupported file format: %s", format)
 	}
 
-	// Merge the decoded object into the given object.
-	err := mergo.Merge(obj, decoded, mergo.WithOverride)
+	return nil
+}
+
+// Populate the object by a file of the format with the given name (looked up in the registry).
+// The file name is passed to the decoder to be reported in errors.
+// Documents of multi-document files are applied in order if selected by the profile.
+// Overrides existing values only if set in the file.
+func populateByFile(formats *formatRegistry, prof profile, r io.Reader, filename string, name string, obj any, opts ...dotpath.Option) error {
+	// Check if the object is a pointer.
+	if reflect.TypeOf(obj).Kind() != reflect.Pointer {
+		return fmt.Errorf("%w: object is not a pointer", ErrInvalidObject)
+	}
+
+	// Detect the format by the content.
+	if name == string(FileFormatAuto) {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read: %w", err)
+		}
+
+		detected, ok := sniffFormat(b)
+		if !ok {
+			return fmt.Errorf("%w: content matches none of %s", ErrUnknownFormat, strings.Join(sniffedFormats, ", "))
+		}
+
+		r = bytes.NewReader(b)
+		name = string(detected)
+	}
+
+	f, ok := formats.lookup(name)
+	if !ok {
+		return fmt.Errorf("unsupported file format: %s", name)
+	}
+
+	// Unmarshal the file by the format.
+	data, err := f.decode(r, filename, reflect.TypeOf(obj), opts...)
 	if err != nil {
-		return fmt.Errorf("failed to merge: %w", err)
+		return fmt.Errorf("%w: %w", ErrDecodeFileFailed, err)
+	}
+
+	layers := []any{data}
+	if docs, ok := data.(documents); ok {
+		layers = slices.DeleteFunc(docs, func(doc any) bool { return !prof.selects(doc, opts...) })
+	}
+
+	for _, data := range layers {
+		// Create a new object of the same type as the given object.
+		decoded := reflectutil.MakeNewObject(reflect.TypeOf(obj))
+
+		// Decode the data into the new object (resolves keys like all other sources).
+		// Flat formats are decoded into values by their paths.
+		if values, ok := data.(pathValues); ok {
+			err = populateByPaths(decoded, values, opts...)
+		} else {
+			err = dotpath.Decode(decoded, data, opts...)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrDecodeFileFailed, err)
+		}
+
+		// Merge the decoded object into the given object.
+		err = mergo.Merge(obj, decoded, mergo.WithOverride)
+		if err != nil {
+			return fmt.Errorf("failed to merge: %w", err)
+		}
 	}
 
 	return nil
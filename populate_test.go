@@ -1,16 +1,29 @@
 package confless
 
 import (
+	"bytes"
 	"flag"
 	"io"
+	"math"
+	"net"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/goccy/go-yaml"
+	"github.com/spf13/afero"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/codetent/confless/pkg/naming"
 )
 
 func Test_populateByFlags(t *testing.T) {
 	tests := []struct {
 		name    string
 		fset    *flag.FlagSet
+		naming  naming.Strategy
 		obj     any
 		wantErr bool
 		verify  func(t *testing.T, obj any)
@@ -195,6 +208,85 @@ func Test_populateByFlags(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "populate field by declared flag names",
+			fset: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.String("db-url", "", "database url")
+				fset.String("d", "", "database url (short)")
+				fset.String("v", "", "verbose")
+				_ = fset.Parse([]string{"--db-url=postgres://localhost", "-v=true"})
+				return fset
+			}(),
+			obj: &struct {
+				URL     string `confless:"flag=db-url,short=d"`
+				Verbose bool   `confless:"short=v"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					URL     string `confless:"flag=db-url,short=d"`
+					Verbose bool   `confless:"short=v"`
+				})
+				if cfg.URL != "postgres://localhost" {
+					t.Errorf("expected URL to be 'postgres://localhost', got '%s'", cfg.URL)
+				}
+				if !cfg.Verbose {
+					t.Errorf("expected Verbose to be true, got %v", cfg.Verbose)
+				}
+			},
+		},
+		{
+			name: "populate renamed subtree by prefix tag",
+			fset: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.String("pg-host", "", "database host")
+				_ = fset.Parse([]string{"--pg-host=localhost"})
+				return fset
+			}(),
+			obj: &struct {
+				Database struct {
+					Host string
+				} `confless:"prefix=pg"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Database struct {
+						Host string
+					} `confless:"prefix=pg"`
+				})
+				if cfg.Database.Host != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", cfg.Database.Host)
+				}
+			},
+		},
+		{
+			name: "populate camel case field by kebab naming",
+			fset: func() *flag.FlagSet {
+				fset := flag.NewFlagSet("test", flag.ContinueOnError)
+				fset.String("db-max-idle-conns", "", "max idle connections")
+				_ = fset.Parse([]string{"--db-max-idle-conns=5"})
+				return fset
+			}(),
+			naming: naming.Kebab,
+			obj: &struct {
+				DB struct {
+					MaxIdleConns int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					DB struct {
+						MaxIdleConns int
+					}
+				})
+				if cfg.DB.MaxIdleConns != 5 {
+					t.Errorf("expected DB.MaxIdleConns to be 5, got %d", cfg.DB.MaxIdleConns)
+				}
+			},
+		},
 		{
 			name: "populate multiple nested fields",
 			fset: func() *flag.FlagSet {
@@ -229,7 +321,7 @@ func Test_populateByFlags(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotErr := populateByFlags(tt.fset, tt.obj)
+			gotErr := populateByFlags(&flagSource{set: tt.fset, naming: tt.naming}, tt.obj)
 			if gotErr != nil {
 				if !tt.wantErr {
 					t.Errorf("populateByFlags() failed: %v", gotErr)
@@ -247,10 +339,17 @@ func Test_populateByFlags(t *testing.T) {
 }
 
 func Test_populateByEnv(t *testing.T) {
+	type Common struct {
+		LogLevel string `json:"log_level"`
+	}
+
 	tests := []struct {
 		name    string
 		env     []string
 		pre     string
+		nesting envNesting
+		naming  naming.Strategy
+		files   map[string]string
 		obj     any
 		wantErr bool
 		verify  func(t *testing.T, obj any)
@@ -309,6 +408,23 @@ func Test_populateByEnv(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "populate field excluded from XML only",
+			env:  []string{"APP_TOKEN=secret"},
+			pre:  "APP",
+			obj: &struct {
+				Token string `xml:"-"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Token string `xml:"-"`
+				})
+				if cfg.Token != "secret" {
+					t.Errorf("expected Token to be 'secret', got '%s'", cfg.Token)
+				}
+			},
+		},
 		{
 			name: "populate nested field with underscore notation",
 			env:  []string{"APP_DATABASE_HOST=localhost"},
@@ -437,235 +553,227 @@ func Test_populateByEnv(t *testing.T) {
 			},
 		},
 		{
-			name: "ignore invalid env var format",
-			env:  []string{"APP_NAME", "APP_PORT=8080"},
+			name: "populate promoted field of embedded struct",
+			env:  []string{"APP_LOGLEVEL=debug"},
 			pre:  "APP",
 			obj: &struct {
-				Name string
-				Port int
+				Common
 			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
-				cfg := obj.(*struct {
-					Name string
-					Port int
-				})
-				if cfg.Name != "" {
-					t.Errorf("expected Name to be empty (invalid env var), got '%s'", cfg.Name)
-				}
-				if cfg.Port != 8080 {
-					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				cfg := obj.(*struct{ Common })
+				if cfg.LogLevel != "debug" {
+					t.Errorf("expected LogLevel to be 'debug', got '%s'", cfg.LogLevel)
 				}
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotErr := populateByEnv(tt.env, tt.pre, tt.obj)
-			if gotErr != nil {
-				if !tt.wantErr {
-					t.Errorf("populateByEnv() failed: %v", gotErr)
-				}
-				return
-			}
-			if tt.wantErr {
-				t.Fatal("populateByEnv() succeeded unexpectedly")
-			}
-			if tt.verify != nil {
-				tt.verify(t, tt.obj)
-			}
-		})
-	}
-}
-
-func Test_populateByFile(t *testing.T) {
-	tests := []struct {
-		name    string
-		r       io.Reader
-		format  string
-		obj     any
-		wantErr bool
-		verify  func(t *testing.T, obj any)
-	}{
-		{
-			name:   "error when object is not a pointer",
-			r:      strings.NewReader(`{"name": "MyApp"}`),
-			format: "json",
-			obj: struct {
-				Name string
-			}{},
-			wantErr: true,
-		},
 		{
-			name:   "populate from JSON file",
-			r:      strings.NewReader(`{"name": "MyApp", "port": 8080}`),
-			format: "json",
+			name: "populate field by declared name without prefix",
+			env:  []string{"PGHOST=db.example.com"},
+			pre:  "APP",
 			obj: &struct {
-				Name string
-				Port int
+				Host string `confless:"env=PGHOST"`
 			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
 				cfg := obj.(*struct {
-					Name string
-					Port int
+					Host string `confless:"env=PGHOST"`
 				})
-				if cfg.Name != "MyApp" {
-					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
-				}
-				if cfg.Port != 8080 {
-					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				if cfg.Host != "db.example.com" {
+					t.Errorf("expected Host to be 'db.example.com', got '%s'", cfg.Host)
 				}
 			},
 		},
 		{
-			name:   "populate from YAML file",
-			r:      strings.NewReader("name: MyApp\nport: 8080"),
-			format: "yaml",
+			name: "populate field by declared name with prefix",
+			env:  []string{"APP_DATABASE_URL=postgres://localhost"},
+			pre:  "APP",
 			obj: &struct {
-				Name string
-				Port int
+				URL string `confless:"env=DATABASE_URL"`
 			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
 				cfg := obj.(*struct {
-					Name string
-					Port int
+					URL string `confless:"env=DATABASE_URL"`
 				})
-				if cfg.Name != "MyApp" {
-					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
-				}
-				if cfg.Port != 8080 {
-					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				if cfg.URL != "postgres://localhost" {
+					t.Errorf("expected URL to be 'postgres://localhost', got '%s'", cfg.URL)
 				}
 			},
 		},
 		{
-			name:   "merge with existing values",
-			r:      strings.NewReader(`{"port": 9000}`),
-			format: "json",
+			name: "first declared name wins",
+			env:  []string{"PGURL=second", "DATABASE_URL=first"},
+			pre:  "APP",
 			obj: &struct {
-				Name string
-				Port int
-			}{
-				Name: "DefaultApp",
-				Port: 8080,
-			},
+				URL string `confless:"env=DATABASE_URL|PGURL"`
+			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
 				cfg := obj.(*struct {
-					Name string
-					Port int
+					URL string `confless:"env=DATABASE_URL|PGURL"`
 				})
-				if cfg.Name != "DefaultApp" {
-					t.Errorf("expected Name to remain 'DefaultApp', got '%s'", cfg.Name)
-				}
-				if cfg.Port != 9000 {
-					t.Errorf("expected Port to be 9000 (overridden), got %d", cfg.Port)
+				if cfg.URL != "first" {
+					t.Errorf("expected URL to be 'first', got '%s'", cfg.URL)
 				}
 			},
 		},
 		{
-			name:   "populate nested structure from JSON",
-			r:      strings.NewReader(`{"database": {"host": "localhost", "port": 5432}}`),
-			format: "json",
+			name: "populate renamed subtree by prefix tag",
+			env:  []string{"APP_PG_HOST=localhost"},
+			pre:  "APP",
 			obj: &struct {
 				Database struct {
 					Host string
-					Port int
-				}
+				} `confless:"prefix=pg"`
 			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
 				cfg := obj.(*struct {
 					Database struct {
 						Host string
-						Port int
-					}
+					} `confless:"prefix=pg"`
 				})
 				if cfg.Database.Host != "localhost" {
 					t.Errorf("expected Database.Host to be 'localhost', got '%s'", cfg.Database.Host)
 				}
-				if cfg.Database.Port != 5432 {
-					t.Errorf("expected Database.Port to be 5432, got %d", cfg.Database.Port)
-				}
 			},
 		},
 		{
-			name:   "populate nested structure from YAML",
-			r:      strings.NewReader("database:\n  host: localhost\n  port: 5432"),
-			format: "yaml",
+			name:    "populate snake_case field by longest match",
+			env:     []string{"APP_DB_MAX_CONNS=10"},
+			pre:     "APP",
+			nesting: EnvNestingLongestMatch,
 			obj: &struct {
-				Database struct {
-					Host string
-					Port int
+				DB struct {
+					MaxConns int `json:"max_conns"`
 				}
 			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
 				cfg := obj.(*struct {
-					Database struct {
-						Host string
-						Port int
+					DB struct {
+						MaxConns int `json:"max_conns"`
 					}
 				})
-				if cfg.Database.Host != "localhost" {
-					t.Errorf("expected Database.Host to be 'localhost', got '%s'", cfg.Database.Host)
-				}
-				if cfg.Database.Port != 5432 {
-					t.Errorf("expected Database.Port to be 5432, got %d", cfg.Database.Port)
+				if cfg.DB.MaxConns != 10 {
+					t.Errorf("expected DB.MaxConns to be 10, got %d", cfg.DB.MaxConns)
 				}
 			},
 		},
 		{
-			name:   "error for unsupported format",
-			r:      strings.NewReader(`{"name": "MyApp"}`),
-			format: "xml",
+			name:    "populate snake_case field by double underscore",
+			env:     []string{"APP_DB__MAX_CONNS=10"},
+			pre:     "APP",
+			nesting: EnvNestingDoubleUnderscore,
 			obj: &struct {
-				Name string
+				DB struct {
+					MaxConns int `json:"max_conns"`
+				}
 			}{},
-			wantErr: true,
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					DB struct {
+						MaxConns int `json:"max_conns"`
+					}
+				})
+				if cfg.DB.MaxConns != 10 {
+					t.Errorf("expected DB.MaxConns to be 10, got %d", cfg.DB.MaxConns)
+				}
+			},
 		},
 		{
-			name:   "error for invalid JSON",
-			r:      strings.NewReader(`{"name": "MyApp"`),
-			format: "json",
+			name:   "populate camel case field by screaming snake naming",
+			env:    []string{"APP_DB_MAX_IDLE_CONNS=5"},
+			pre:    "APP",
+			naming: naming.ScreamingSnake,
 			obj: &struct {
-				Name string
+				DB struct {
+					MaxIdleConns int
+				}
 			}{},
-			wantErr: true,
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					DB struct {
+						MaxIdleConns int
+					}
+				})
+				if cfg.DB.MaxIdleConns != 5 {
+					t.Errorf("expected DB.MaxIdleConns to be 5, got %d", cfg.DB.MaxIdleConns)
+				}
+			},
 		},
 		{
-			name:   "error for invalid YAML",
-			r:      strings.NewReader("name: [invalid"),
-			format: "yaml",
+			name:    "populate camel case field by naming with double underscore",
+			env:     []string{"APP_DB__MAX_IDLE_CONNS=5"},
+			pre:     "APP",
+			nesting: EnvNestingDoubleUnderscore,
+			naming:  naming.ScreamingSnake,
 			obj: &struct {
-				Name string
+				DB struct {
+					MaxIdleConns int
+				}
 			}{},
-			wantErr: true,
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					DB struct {
+						MaxIdleConns int
+					}
+				})
+				if cfg.DB.MaxIdleConns != 5 {
+					t.Errorf("expected DB.MaxIdleConns to be 5, got %d", cfg.DB.MaxIdleConns)
+				}
+			},
 		},
 		{
-			name:   "populate array from JSON",
-			r:      strings.NewReader(`{"items": [1, 2, 3]}`),
-			format: "json",
+			name:  "populate field from file referenced by _FILE suffix",
+			env:   []string{"APP_DB_PASSWORD_FILE=/run/secrets/db_pw"},
+			pre:   "APP",
+			files: map[string]string{"/run/secrets/db_pw": "s3cret\n"},
 			obj: &struct {
-				Items []int
+				DB struct {
+					Password string
+				}
 			}{},
 			wantErr: false,
 			verify: func(t *testing.T, obj any) {
-				cfg := obj.(*struct{ Items []int })
-				if len(cfg.Items) != 3 {
-					t.Errorf("expected Items length to be 3, got %d", len(cfg.Items))
+				cfg := obj.(*struct {
+					DB struct {
+						Password string
+					}
+				})
+				if cfg.DB.Password != "s3cret" {
+					t.Errorf("expected DB.Password to be 's3cret', got '%s'", cfg.DB.Password)
 				}
-				if cfg.Items[0] != 1 || cfg.Items[1] != 2 || cfg.Items[2] != 3 {
-					t.Errorf("expected Items to be [1, 2, 3], got %v", cfg.Items)
+			},
+		},
+		{
+			name:  "populate declared field from file referenced by _FILE suffix",
+			env:   []string{"DATABASE_URL_FILE=/run/secrets/db_url"},
+			pre:   "APP",
+			files: map[string]string{"/run/secrets/db_url": "postgres://localhost\r\n"},
+			obj: &struct {
+				URL string `confless:"env=DATABASE_URL"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					URL string `confless:"env=DATABASE_URL"`
+				})
+				if cfg.URL != "postgres://localhost" {
+					t.Errorf("expected URL to be 'postgres://localhost', got '%s'", cfg.URL)
 				}
 			},
 		},
 		{
-			name:   "populate with json tag",
-			r:      strings.NewReader(`{"config_file": "production.json"}`),
-			format: "json",
+			name:    "populate field ending with file if not referencing a field",
+			env:     []string{"APP_CONFIG_FILE=config.yaml"},
+			pre:     "APP",
+			nesting: EnvNestingLongestMatch,
+			files:   map[string]string{},
 			obj: &struct {
 				ConfigFile string `json:"config_file"`
 			}{},
@@ -674,17 +782,1289 @@ func Test_populateByFile(t *testing.T) {
 				cfg := obj.(*struct {
 					ConfigFile string `json:"config_file"`
 				})
-				if cfg.ConfigFile != "production.json" {
-					t.Errorf("expected ConfigFile to be 'production.json', got '%s'", cfg.ConfigFile)
+				if cfg.ConfigFile != "config.yaml" {
+					t.Errorf("expected ConfigFile to be 'config.yaml', got '%s'", cfg.ConfigFile)
 				}
 			},
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			gotErr := populateByFile(tt.r, tt.format, tt.obj)
-			if gotErr != nil {
-				if !tt.wantErr {
+		{
+			name:  "error when value and _FILE suffix are set",
+			env:   []string{"APP_PASSWORD=plain", "APP_PASSWORD_FILE=/run/secrets/pw"},
+			pre:   "APP",
+			files: map[string]string{"/run/secrets/pw": "s3cret"},
+			obj: &struct {
+				Password string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:  "error when referenced file does not exist",
+			env:   []string{"APP_PASSWORD_FILE=/run/secrets/missing"},
+			pre:   "APP",
+			files: map[string]string{},
+			obj: &struct {
+				Password string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:    "ignore _FILE suffix if not enabled",
+			env:     []string{"APP_PASSWORD_FILE=/run/secrets/pw"},
+			pre:     "APP",
+			nesting: EnvNestingLongestMatch,
+			obj: &struct {
+				Password     string
+				PasswordFile string `json:"password_file"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Password     string
+					PasswordFile string `json:"password_file"`
+				})
+				if cfg.Password != "" || cfg.PasswordFile != "/run/secrets/pw" {
+					t.Errorf("expected only PasswordFile to be set, got '%s' and '%s'", cfg.Password, cfg.PasswordFile)
+				}
+			},
+		},
+		{
+			name: "error for snake_case field with underscore nesting",
+			env:  []string{"APP_DB_MAX_CONNS=10"},
+			pre:  "APP",
+			obj: &struct {
+				DB struct {
+					MaxConns int `json:"max_conns"`
+				}
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "error for ignored field",
+			env:  []string{"APP_INTERNAL=value"},
+			pre:  "APP",
+			obj: &struct {
+				Internal string `confless:"-"`
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "ignore invalid env var format",
+			env:  []string{"APP_NAME", "APP_PORT=8080"},
+			pre:  "APP",
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "" {
+					t.Errorf("expected Name to be empty (invalid env var), got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				_ = afero.WriteFile(fs, path, []byte(content), 0644)
+			}
+
+			src := &envSource{prefix: tt.pre, nesting: tt.nesting, naming: tt.naming, files: tt.files != nil}
+			_, gotErr := populateByEnv(fs, tt.env, src, tt.obj)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("populateByEnv() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("populateByEnv() succeeded unexpectedly")
+			}
+			if tt.verify != nil {
+				tt.verify(t, tt.obj)
+			}
+		})
+	}
+}
+
+func Test_populateByEnv_FileContentNotInError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/run/secrets/port", []byte("s3cret\n"), 0644)
+
+	obj := &struct {
+		Port int
+	}{}
+
+	_, err := populateByEnv(fs, []string{"APP_PORT_FILE=/run/secrets/port"}, &envSource{prefix: "APP", files: true}, obj)
+	if err == nil {
+		t.Fatal("populateByEnv() succeeded unexpectedly")
+	}
+	if strings.Contains(err.Error(), "s3cret") {
+		t.Errorf("expected error not to contain the file content, got: %v", err)
+	}
+}
+
+func Test_populateByEnv_ValueNotInError(t *testing.T) {
+	type Config struct {
+		Port int
+		URL  int `confless:"env=DATABASE_URL"`
+	}
+
+	tests := []struct {
+		name     string
+		env      []string
+		wantName string
+	}{
+		{name: "prefixed variable", env: []string{"APP_PORT=s3cret"}, wantName: "APP_PORT"},
+		{name: "declared name", env: []string{"DATABASE_URL=s3cret"}, wantName: "DATABASE_URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := populateByEnv(afero.NewMemMapFs(), tt.env, &envSource{prefix: "APP"}, &Config{})
+			if err == nil {
+				t.Fatal("populateByEnv() succeeded unexpectedly")
+			}
+			if strings.Contains(err.Error(), "s3cret") {
+				t.Errorf("expected error not to contain the value, got: %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantName) {
+				t.Errorf("expected error to contain %s, got: %v", tt.wantName, err)
+			}
+		})
+	}
+}
+
+func Test_populateByDir(t *testing.T) {
+	type Config struct {
+		Name     string
+		Port     int
+		Database struct {
+			Host     string
+			Password string
+		}
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		sep     string
+		wantErr bool
+		want    Config
+	}{
+		{
+			name:  "populate fields by file names",
+			files: map[string]string{"secrets/name": "MyApp\n", "secrets/port": "8080"},
+			sep:   ".",
+			want:  Config{Name: "MyApp", Port: 8080},
+		},
+		{
+			name:  "populate nested fields by dot separator",
+			files: map[string]string{"secrets/database.host": "localhost", "secrets/database.password": "s3cret\n"},
+			sep:   ".",
+			want: func() Config {
+				c := Config{}
+				c.Database.Host = "localhost"
+				c.Database.Password = "s3cret"
+				return c
+			}(),
+		},
+		{
+			name:  "populate nested fields by custom separator",
+			files: map[string]string{"secrets/database__host": "localhost"},
+			sep:   "__",
+			want: func() Config {
+				c := Config{}
+				c.Database.Host = "localhost"
+				return c
+			}(),
+		},
+		{
+			name: "skip kubernetes bookkeeping entries and subdirectories",
+			files: map[string]string{
+				"secrets/..data/name":                "ignored",
+				"secrets/..2024_01_01_00_00_00/name": "ignored",
+				"secrets/database/host":              "ignored",
+				"secrets/name":                       "MyApp",
+			},
+			sep:  ".",
+			want: Config{Name: "MyApp"},
+		},
+		{
+			name:  "skip unknown keys",
+			files: map[string]string{"secrets/unknown": "value", "secrets/name": "MyApp"},
+			sep:   ".",
+			want:  Config{Name: "MyApp"},
+		},
+		{
+			name:  "skip missing directory",
+			files: map[string]string{},
+			sep:   ".",
+			want:  Config{},
+		},
+		{
+			name:    "error for invalid value",
+			files:   map[string]string{"secrets/port": "invalid"},
+			sep:     ".",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				_ = afero.WriteFile(fs, path, []byte(content), 0644)
+			}
+
+			obj := &Config{}
+			gotErr := populateByDir(fs, &dirSource{path: "secrets", separator: tt.sep}, obj)
+			if gotErr != nil {
+				if !tt.wantErr {
+					t.Errorf("populateByDir() failed: %v", gotErr)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("populateByDir() succeeded unexpectedly")
+			}
+			if *obj != tt.want {
+				t.Errorf("populateByDir() = %+v, want %+v", *obj, tt.want)
+			}
+		})
+	}
+}
+
+func Test_populateByDir_UnknownKeysNotAllocated(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "secrets/database.unknown", []byte("1"), 0644)
+
+	obj := &struct {
+		Database *struct {
+			Host string
+		}
+	}{}
+
+	err := populateByDir(fs, &dirSource{path: "secrets", separator: "."}, obj)
+	if err != nil {
+		t.Fatalf("populateByDir() failed: %v", err)
+	}
+	if obj.Database != nil {
+		t.Errorf("expected Database not to be allocated, got %+v", obj.Database)
+	}
+}
+
+func Test_populateByFile(t *testing.T) {
+	type Common struct {
+		LogLevel string `json:"log_level"`
+	}
+
+	tests := []struct {
+		name    string
+		r       io.Reader
+		format  string
+		obj     any
+		wantErr bool
+		verify  func(t *testing.T, obj any)
+	}{
+		{
+			name:   "error when object is not a pointer",
+			r:      strings.NewReader(`{"name": "MyApp"}`),
+			format: "json",
+			obj: struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "populate large integers from JSON5 file",
+			r:      strings.NewReader("{id: 9007199254740993, mask: 0x7FFFFFFFFFFFFFFF}"),
+			format: "json5",
+			obj: &struct {
+				ID   int64
+				Mask uint64
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					ID   int64
+					Mask uint64
+				})
+				if cfg.ID != 9007199254740993 {
+					t.Errorf("expected ID to be 9007199254740993, got %d", cfg.ID)
+				}
+				if cfg.Mask != math.MaxInt64 {
+					t.Errorf("expected Mask to be %d, got %d", uint64(math.MaxInt64), cfg.Mask)
+				}
+			},
+		},
+		{
+			name:   "populate from JSON file",
+			r:      strings.NewReader(`{"name": "MyApp", "port": 8080}`),
+			format: "json",
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name:   "populate from YAML file",
+			r:      strings.NewReader("name: MyApp\nport: 8080"),
+			format: "yaml",
+			obj: &struct {
+				Name string
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name:   "merge with existing values",
+			r:      strings.NewReader(`{"port": 9000}`),
+			format: "json",
+			obj: &struct {
+				Name string
+				Port int
+			}{
+				Name: "DefaultApp",
+				Port: 8080,
+			},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+					Port int
+				})
+				if cfg.Name != "DefaultApp" {
+					t.Errorf("expected Name to remain 'DefaultApp', got '%s'", cfg.Name)
+				}
+				if cfg.Port != 9000 {
+					t.Errorf("expected Port to be 9000 (overridden), got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name:   "populate nested structure from JSON",
+			r:      strings.NewReader(`{"database": {"host": "localhost", "port": 5432}}`),
+			format: "json",
+			obj: &struct {
+				Database struct {
+					Host string
+					Port int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Database struct {
+						Host string
+						Port int
+					}
+				})
+				if cfg.Database.Host != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", cfg.Database.Host)
+				}
+				if cfg.Database.Port != 5432 {
+					t.Errorf("expected Database.Port to be 5432, got %d", cfg.Database.Port)
+				}
+			},
+		},
+		{
+			name:   "populate nested structure from YAML",
+			r:      strings.NewReader("database:\n  host: localhost\n  port: 5432"),
+			format: "yaml",
+			obj: &struct {
+				Database struct {
+					Host string
+					Port int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Database struct {
+						Host string
+						Port int
+					}
+				})
+				if cfg.Database.Host != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", cfg.Database.Host)
+				}
+				if cfg.Database.Port != 5432 {
+					t.Errorf("expected Database.Port to be 5432, got %d", cfg.Database.Port)
+				}
+			},
+		},
+		{
+			name:   "populate nested structure from TOML with toml tags",
+			r:      strings.NewReader("name = \"MyApp\"\nmax_conns = 10\n\n[database]\nhost = \"localhost\"\nports = [5432, 5433]\n"),
+			format: "toml",
+			obj: &struct {
+				Name     string
+				MaxConns int `toml:"max_conns"`
+				Database struct {
+					Host  string
+					Ports []int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name     string
+					MaxConns int `toml:"max_conns"`
+					Database struct {
+						Host  string
+						Ports []int
+					}
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.MaxConns != 10 {
+					t.Errorf("expected MaxConns to be 10, got %d", cfg.MaxConns)
+				}
+				if cfg.Database.Host != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", cfg.Database.Host)
+				}
+				if len(cfg.Database.Ports) != 2 || cfg.Database.Ports[1] != 5433 {
+					t.Errorf("expected Database.Ports to be [5432 5433], got %v", cfg.Database.Ports)
+				}
+			},
+		},
+		{
+			name:   "populate time fields from TOML local date-time and date",
+			r:      strings.NewReader("start = 2020-01-01T10:30:00\nday = 2020-01-02\n"),
+			format: "toml",
+			obj: &struct {
+				Start time.Time
+				Day   time.Time
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Start time.Time
+					Day   time.Time
+				})
+				if want := time.Date(2020, 1, 1, 10, 30, 0, 0, time.Local); !cfg.Start.Equal(want) {
+					t.Errorf("expected Start to be %v, got %v", want, cfg.Start)
+				}
+				if want := time.Date(2020, 1, 2, 0, 0, 0, 0, time.Local); !cfg.Day.Equal(want) {
+					t.Errorf("expected Day to be %v, got %v", want, cfg.Day)
+				}
+			},
+		},
+		{
+			name:   "error for invalid TOML",
+			r:      strings.NewReader("name = "),
+			format: "toml",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "populate nested structure from HCL blocks",
+			r: strings.NewReader(`
+name = "MyApp"
+tags = ["a", "b"]
+
+database {
+  host = "localhost"
+  port = 5432
+}
+`),
+			format: "hcl",
+			obj: &struct {
+				Name     string
+				Tags     []string
+				Database struct {
+					Host string
+					Port int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name     string
+					Tags     []string
+					Database struct {
+						Host string
+						Port int
+					}
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+					t.Errorf("expected Tags to be [a b], got %v", cfg.Tags)
+				}
+				if cfg.Database.Host != "localhost" || cfg.Database.Port != 5432 {
+					t.Errorf("expected Database to be localhost:5432, got %s:%d", cfg.Database.Host, cfg.Database.Port)
+				}
+			},
+		},
+		{
+			name: "populate map from labelled HCL blocks",
+			r: strings.NewReader(`
+service "web" {
+  port = 80
+}
+
+service "api" {
+  port = 8080
+}
+`),
+			format: "hcl",
+			obj: &struct {
+				Service map[string]struct {
+					Port int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Service map[string]struct {
+						Port int
+					}
+				})
+				if len(cfg.Service) != 2 || cfg.Service["web"].Port != 80 || cfg.Service["api"].Port != 8080 {
+					t.Errorf("expected Service to contain web:80 and api:8080, got %v", cfg.Service)
+				}
+			},
+		},
+		{
+			name: "populate slice from labelled HCL blocks",
+			r: strings.NewReader(`
+listener "http" {
+  port = 80
+}
+
+listener "https" {
+  port = 443
+}
+`),
+			format: "hcl",
+			obj: &struct {
+				Listeners []struct {
+					Name string `confless:"label"`
+					Port int
+				} `json:"listener"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Listeners []struct {
+						Name string `confless:"label"`
+						Port int
+					} `json:"listener"`
+				})
+				if len(cfg.Listeners) != 2 {
+					t.Fatalf("expected 2 listeners, got %d", len(cfg.Listeners))
+				}
+				if cfg.Listeners[0].Name != "http" || cfg.Listeners[0].Port != 80 {
+					t.Errorf("expected first listener to be http:80, got %s:%d", cfg.Listeners[0].Name, cfg.Listeners[0].Port)
+				}
+				if cfg.Listeners[1].Name != "https" || cfg.Listeners[1].Port != 443 {
+					t.Errorf("expected second listener to be https:443, got %s:%d", cfg.Listeners[1].Name, cfg.Listeners[1].Port)
+				}
+			},
+		},
+		{
+			name:   "error for invalid HCL",
+			r:      strings.NewReader("name = "),
+			format: "hcl",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "error for HCL with variables",
+			r:      strings.NewReader("name = var.name"),
+			format: "hcl",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "populate nested structure from INI sections",
+			r: strings.NewReader(`
+name = MyApp
+debug = true
+
+[database]
+host = localhost
+port = 5432
+
+[database.replica]
+host = replica
+`),
+			format: "ini",
+			obj: &struct {
+				Name     string
+				Debug    bool
+				Database struct {
+					Host    string
+					Port    int
+					Replica struct {
+						Host string
+					}
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name     string
+					Debug    bool
+					Database struct {
+						Host    string
+						Port    int
+						Replica struct {
+							Host string
+						}
+					}
+				})
+				if cfg.Name != "MyApp" || !cfg.Debug {
+					t.Errorf("expected Name to be 'MyApp' and Debug to be true, got '%s' and %v", cfg.Name, cfg.Debug)
+				}
+				if cfg.Database.Host != "localhost" || cfg.Database.Port != 5432 {
+					t.Errorf("expected Database to be localhost:5432, got %s:%d", cfg.Database.Host, cfg.Database.Port)
+				}
+				if cfg.Database.Replica.Host != "replica" {
+					t.Errorf("expected Database.Replica.Host to be 'replica', got '%s'", cfg.Database.Replica.Host)
+				}
+			},
+		},
+		{
+			name:   "populate pointer section from INI",
+			r:      strings.NewReader("[database]\nhost = localhost\n\n[database.replica]\nhost = replica"),
+			format: "ini",
+			obj: &struct {
+				Database *struct {
+					Host    string
+					Replica *struct {
+						Host string
+					}
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Database *struct {
+						Host    string
+						Replica *struct {
+							Host string
+						}
+					}
+				})
+				if cfg.Database == nil || cfg.Database.Host != "localhost" {
+					t.Fatalf("expected Database.Host to be 'localhost', got %+v", cfg.Database)
+				}
+				if cfg.Database.Replica == nil || cfg.Database.Replica.Host != "replica" {
+					t.Errorf("expected Database.Replica.Host to be 'replica', got %+v", cfg.Database.Replica)
+				}
+			},
+		},
+		{
+			name:   "error for invalid INI value",
+			r:      strings.NewReader("port = invalid"),
+			format: "ini",
+			obj: &struct {
+				Port int
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "populate nested structure from properties",
+			r: strings.NewReader(`
+# comment
+name=MyApp
+database.host = localhost
+database.port: 5432
+unknown.key = ignored
+`),
+			format: "properties",
+			obj: &struct {
+				Name     string
+				Database struct {
+					Host string
+					Port int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name     string
+					Database struct {
+						Host string
+						Port int
+					}
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Database.Host != "localhost" || cfg.Database.Port != 5432 {
+					t.Errorf("expected Database to be localhost:5432, got %s:%d", cfg.Database.Host, cfg.Database.Port)
+				}
+			},
+		},
+		{
+			name: "populate slice elements from properties",
+			r: strings.NewReader(`
+servers.1.host = b
+servers.0.host = a
+servers.0.port = 8080
+tags.0 = x
+`),
+			format: "properties",
+			obj: &struct {
+				Tags    []string
+				Servers []struct {
+					Host string
+					Port int
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Tags    []string
+					Servers []struct {
+						Host string
+						Port int
+					}
+				})
+				if len(cfg.Tags) != 1 || cfg.Tags[0] != "x" {
+					t.Errorf("expected Tags to be [x], got %v", cfg.Tags)
+				}
+				if len(cfg.Servers) != 2 || cfg.Servers[0].Host != "a" || cfg.Servers[0].Port != 8080 || cfg.Servers[1].Host != "b" {
+					t.Errorf("expected Servers to be [{a 8080} {b 0}], got %+v", cfg.Servers)
+				}
+			},
+		},
+		{
+			name:   "skip properties key with gap in slice indexes",
+			r:      strings.NewReader("ports.0 = 80\nports.5 = 443"),
+			format: "properties",
+			obj: &struct {
+				Ports []int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Ports []int
+				})
+				if !reflect.DeepEqual(cfg.Ports, []int{80}) {
+					t.Errorf("expected Ports to be [80], got %v", cfg.Ports)
+				}
+			},
+		},
+		{
+			name:   "skip properties key below basic value",
+			r:      strings.NewReader("name = MyApp\nname.sub = 1"),
+			format: "properties",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name string
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+			},
+		},
+		{
+			name:   "error for properties value of invalid type",
+			r:      strings.NewReader("port = abc"),
+			format: "properties",
+			obj: &struct {
+				Port int
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "populate nested structure from XML elements and attributes",
+			r: strings.NewReader(`<?xml version="1.0"?>
+<config version="2">
+  <!-- comment -->
+  <name>MyApp</name>
+  <database host="localhost" port="5432"/>
+  <listener port="80">http</listener>
+  <tag>a</tag>
+  <tag>b</tag>
+  <server><port>8080</port></server>
+  <server><port>8081</port></server>
+</config>`),
+			format: "xml",
+			obj: &struct {
+				Version  int `xml:"version,attr"`
+				Name     string
+				Database struct {
+					Host string `xml:"host,attr"`
+					Port int    `xml:"port,attr"`
+				}
+				Listener struct {
+					Protocol string `xml:",chardata"`
+					Port     int    `xml:"port,attr"`
+				}
+				Tags    []string `xml:"tag"`
+				Servers []struct {
+					Port int
+				} `xml:"server"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := reflect.ValueOf(obj).Elem()
+				if v := cfg.FieldByName("Version").Int(); v != 2 {
+					t.Errorf("expected Version to be 2, got %d", v)
+				}
+				if v := cfg.FieldByName("Name").String(); v != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", v)
+				}
+				if v := cfg.FieldByName("Database").FieldByName("Host").String(); v != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", v)
+				}
+				if v := cfg.FieldByName("Database").FieldByName("Port").Int(); v != 5432 {
+					t.Errorf("expected Database.Port to be 5432, got %d", v)
+				}
+				if v := cfg.FieldByName("Listener").FieldByName("Protocol").String(); v != "http" {
+					t.Errorf("expected Listener.Protocol to be 'http', got '%s'", v)
+				}
+				if v := cfg.FieldByName("Tags").Interface().([]string); len(v) != 2 || v[1] != "b" {
+					t.Errorf("expected Tags to be [a b], got %v", v)
+				}
+				if v := cfg.FieldByName("Servers"); v.Len() != 2 || v.Index(1).FieldByName("Port").Int() != 8081 {
+					t.Errorf("expected 2 servers with ports 8080 and 8081, got %v", v.Interface())
+				}
+			},
+		},
+		{
+			name:   "populate slice from single XML element",
+			r:      strings.NewReader(`<config><tag>a</tag></config>`),
+			format: "xml",
+			obj: &struct {
+				Tags []string `xml:"tag"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Tags []string `xml:"tag"`
+				})
+				if len(cfg.Tags) != 1 || cfg.Tags[0] != "a" {
+					t.Errorf("expected Tags to be [a], got %v", cfg.Tags)
+				}
+			},
+		},
+		{
+			name:   "error for invalid XML",
+			r:      strings.NewReader(`<config><name>MyApp</config>`),
+			format: "xml",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "populate net.IP, time.Duration and time.Time from JSON",
+			r:      strings.NewReader(`{"ip": "10.0.0.1", "timeout": "5s", "started": "2024-01-02T03:04:05Z"}`),
+			format: "json",
+			obj: &struct {
+				IP      net.IP
+				Timeout time.Duration
+				Started time.Time
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					IP      net.IP
+					Timeout time.Duration
+					Started time.Time
+				})
+				if !cfg.IP.Equal(net.IPv4(10, 0, 0, 1)) {
+					t.Errorf("expected IP to be 10.0.0.1, got %v", cfg.IP)
+				}
+				if cfg.Timeout != 5*time.Second {
+					t.Errorf("expected Timeout to be 5s, got %v", cfg.Timeout)
+				}
+				if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !cfg.Started.Equal(want) {
+					t.Errorf("expected Started to be %v, got %v", want, cfg.Started)
+				}
+			},
+		},
+		{
+			name:   "populate net.IP, time.Duration and time.Time from YAML",
+			r:      strings.NewReader("ip: 10.0.0.1\ntimeout: 5s\nstarted: 2024-01-02T03:04:05Z"),
+			format: "yaml",
+			obj: &struct {
+				IP      net.IP
+				Timeout time.Duration
+				Started time.Time
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					IP      net.IP
+					Timeout time.Duration
+					Started time.Time
+				})
+				if !cfg.IP.Equal(net.IPv4(10, 0, 0, 1)) {
+					t.Errorf("expected IP to be 10.0.0.1, got %v", cfg.IP)
+				}
+				if cfg.Timeout != 5*time.Second {
+					t.Errorf("expected Timeout to be 5s, got %v", cfg.Timeout)
+				}
+				if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !cfg.Started.Equal(want) {
+					t.Errorf("expected Started to be %v, got %v", want, cfg.Started)
+				}
+			},
+		},
+		{
+			name:   "populate bytes from base64 in JSON",
+			r:      strings.NewReader(`{"data": "aGVsbG8="}`),
+			format: "json",
+			obj: &struct {
+				Data []byte
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Data []byte
+				})
+				if string(cfg.Data) != "hello" {
+					t.Errorf("expected Data to be 'hello', got %q", cfg.Data)
+				}
+			},
+		},
+		{
+			name:   "populate numbers in interfaces as float64 from JSON",
+			r:      strings.NewReader(`{"extra": 1, "meta": {"port": 8080}}`),
+			format: "json",
+			obj: &struct {
+				Extra any
+				Meta  map[string]any
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Extra any
+					Meta  map[string]any
+				})
+				if cfg.Extra != float64(1) {
+					t.Errorf("expected Extra to be float64(1), got %#v", cfg.Extra)
+				}
+				if cfg.Meta["port"] != float64(8080) {
+					t.Errorf("expected Meta.port to be float64(8080), got %#v", cfg.Meta["port"])
+				}
+			},
+		},
+		{
+			name:   "populate YAML unmarshaler from YAML",
+			r:      strings.NewReader("y: hello"),
+			format: "yaml",
+			obj: &struct {
+				Y upperYAML
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Y upperYAML
+				})
+				if cfg.Y != "HELLO" {
+					t.Errorf("expected Y to be 'HELLO', got '%s'", cfg.Y)
+				}
+			},
+		},
+		{
+			name: "populate from CBOR",
+			r: bytes.NewReader(mustMarshal(cbor.Marshal(map[string]any{
+				"name":       "MyApp",
+				"port":       8080,
+				"log_level":  []byte("debug"),
+				"tags":       []string{"a", "b"},
+				"database":   map[string]any{"host": "localhost"},
+				"debug":      true,
+				"ratio":      0.5,
+				"key":        []byte{0x00, 0x01, 0xff},
+				"unknownKey": "ignored",
+			}))),
+			format: "cbor",
+			obj: &struct {
+				Common
+				Name     string
+				Port     int
+				Tags     []string
+				Debug    bool
+				Ratio    float64
+				Key      []byte
+				Database struct {
+					Host string
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				v := reflect.ValueOf(obj).Elem()
+				if s := v.FieldByName("Name").String(); s != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", s)
+				}
+				if i := v.FieldByName("Port").Int(); i != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", i)
+				}
+				if s := v.FieldByName("LogLevel").String(); s != "debug" {
+					t.Errorf("expected LogLevel to be 'debug', got '%s'", s)
+				}
+				if tags := v.FieldByName("Tags").Interface().([]string); len(tags) != 2 || tags[1] != "b" {
+					t.Errorf("expected Tags to be [a b], got %v", tags)
+				}
+				if !v.FieldByName("Debug").Bool() || v.FieldByName("Ratio").Float() != 0.5 {
+					t.Errorf("expected Debug to be true and Ratio 0.5, got %v and %v", v.FieldByName("Debug"), v.FieldByName("Ratio"))
+				}
+				if s := v.FieldByName("Database").FieldByName("Host").String(); s != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", s)
+				}
+				if b := v.FieldByName("Key").Bytes(); !bytes.Equal(b, []byte{0x00, 0x01, 0xff}) {
+					t.Errorf("expected Key to be [0 1 255], got %v", b)
+				}
+			},
+		},
+		{
+			name:   "error for CBOR with non-string keys",
+			r:      bytes.NewReader(mustMarshal(cbor.Marshal(map[int]string{1: "MyApp"}))),
+			format: "cbor",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "error for invalid CBOR",
+			r:      bytes.NewReader([]byte{0xa1, 0x64}),
+			format: "cbor",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name: "populate from MessagePack",
+			r: bytes.NewReader(mustMarshal(msgpack.Marshal(map[string]any{
+				"name":      "MyApp",
+				"port":      uint16(8080),
+				"log_level": []byte("debug"),
+				"tags":      []string{"a", "b"},
+				"key":       []byte{0x00, 0x01, 0xff},
+				"database":  map[string]any{"host": "localhost", "max_conns": int8(-1)},
+			}))),
+			format: "msgpack",
+			obj: &struct {
+				Common
+				Name     string
+				Port     int
+				Tags     []string
+				Key      []byte
+				Database struct {
+					Host     string
+					MaxConns int `json:"max_conns"`
+				}
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				v := reflect.ValueOf(obj).Elem()
+				if s := v.FieldByName("Name").String(); s != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", s)
+				}
+				if i := v.FieldByName("Port").Int(); i != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", i)
+				}
+				if s := v.FieldByName("LogLevel").String(); s != "debug" {
+					t.Errorf("expected LogLevel to be 'debug', got '%s'", s)
+				}
+				if tags := v.FieldByName("Tags").Interface().([]string); len(tags) != 2 || tags[1] != "b" {
+					t.Errorf("expected Tags to be [a b], got %v", tags)
+				}
+				if s := v.FieldByName("Database").FieldByName("Host").String(); s != "localhost" {
+					t.Errorf("expected Database.Host to be 'localhost', got '%s'", s)
+				}
+				if i := v.FieldByName("Database").FieldByName("MaxConns").Int(); i != -1 {
+					t.Errorf("expected Database.MaxConns to be -1, got %d", i)
+				}
+				if b := v.FieldByName("Key").Bytes(); !bytes.Equal(b, []byte{0x00, 0x01, 0xff}) {
+					t.Errorf("expected Key to be [0 1 255], got %v", b)
+				}
+			},
+		},
+		{
+			name:   "error for MessagePack with non-string keys",
+			r:      bytes.NewReader(mustMarshal(msgpack.Marshal(map[int]string{1: "MyApp"}))),
+			format: "msgpack",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "error for unsupported format",
+			r:      strings.NewReader(`{"name": "MyApp"}`),
+			format: "csv",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "error for invalid JSON",
+			r:      strings.NewReader(`{"name": "MyApp"`),
+			format: "json",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "error for invalid YAML",
+			r:      strings.NewReader("name: [invalid"),
+			format: "yaml",
+			obj: &struct {
+				Name string
+			}{},
+			wantErr: true,
+		},
+		{
+			name:   "populate array from JSON",
+			r:      strings.NewReader(`{"items": [1, 2, 3]}`),
+			format: "json",
+			obj: &struct {
+				Items []int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct{ Items []int })
+				if len(cfg.Items) != 3 {
+					t.Errorf("expected Items length to be 3, got %d", len(cfg.Items))
+				}
+				if cfg.Items[0] != 1 || cfg.Items[1] != 2 || cfg.Items[2] != 3 {
+					t.Errorf("expected Items to be [1, 2, 3], got %v", cfg.Items)
+				}
+			},
+		},
+		{
+			name:   "populate promoted field of embedded struct from YAML",
+			r:      strings.NewReader("log_level: debug\nport: 8080"),
+			format: "yaml",
+			obj: &struct {
+				Common
+				Port int
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Common
+					Port int
+				})
+				if cfg.LogLevel != "debug" {
+					t.Errorf("expected LogLevel to be 'debug', got '%s'", cfg.LogLevel)
+				}
+				if cfg.Port != 8080 {
+					t.Errorf("expected Port to be 8080, got %d", cfg.Port)
+				}
+			},
+		},
+		{
+			name:   "populate inlined struct field from JSON",
+			r:      strings.NewReader(`{"log_level": "debug"}`),
+			format: "json",
+			obj: &struct {
+				Shared Common `confless:"inline"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Shared Common `confless:"inline"`
+				})
+				if cfg.Shared.LogLevel != "debug" {
+					t.Errorf("expected Shared.LogLevel to be 'debug', got '%s'", cfg.Shared.LogLevel)
+				}
+			},
+		},
+		{
+			name:   "skip ignored fields",
+			r:      strings.NewReader(`{"name": "MyApp", "internal": "value", "-": "dash"}`),
+			format: "json",
+			obj: &struct {
+				Name     string
+				Internal string `confless:"-"`
+				Secret   string `json:"-"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					Name     string
+					Internal string `confless:"-"`
+					Secret   string `json:"-"`
+				})
+				if cfg.Name != "MyApp" {
+					t.Errorf("expected Name to be 'MyApp', got '%s'", cfg.Name)
+				}
+				if cfg.Internal != "" || cfg.Secret != "" {
+					t.Errorf("expected ignored fields to be empty, got '%s' and '%s'", cfg.Internal, cfg.Secret)
+				}
+			},
+		},
+		{
+			name:   "populate with json tag",
+			r:      strings.NewReader(`{"config_file": "production.json"}`),
+			format: "json",
+			obj: &struct {
+				ConfigFile string `json:"config_file"`
+			}{},
+			wantErr: false,
+			verify: func(t *testing.T, obj any) {
+				cfg := obj.(*struct {
+					ConfigFile string `json:"config_file"`
+				})
+				if cfg.ConfigFile != "production.json" {
+					t.Errorf("expected ConfigFile to be 'production.json', got '%s'", cfg.ConfigFile)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotErr := populateByFile(defaultFormats, profile{}, tt.r, "config", tt.format, tt.obj)
+			if gotErr != nil {
+				if !tt.wantErr {
 					t.Errorf("populateByFile() failed: %v", gotErr)
 				}
 				return
@@ -698,3 +2078,25 @@ func Test_populateByFile(t *testing.T) {
 		})
 	}
 }
+
+// Returns the marshalled data or panics on errors.
+func mustMarshal(b []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// Upper-case string decoded by a YAML unmarshaler.
+type upperYAML string
+
+func (u *upperYAML) UnmarshalYAML(b []byte) error {
+	var s string
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	*u = upperYAML(strings.ToUpper(s))
+	return nil
+}
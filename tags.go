@@ -5,10 +5,11 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/codetent/confless/pkg/dotpath"
 	"github.com/codetent/confless/pkg/reflectutil"
 )
 
-// Parses the tag into a map of key-value pairs.
+// Parses the confless tag into a map of key-value pairs.
 // For example, the tag "file,format=yaml" will be parsed into:
 //
 //	{
@@ -16,67 +17,123 @@ import (
 //	  "format": "yaml",
 //	}
 func parseTag(t reflect.StructTag) map[string]string {
-	tag := t.Get("confless")
+	return reflectutil.ParseTag(t, "confless")
+}
 
-	kvs := make(map[string]string)
-	if tag == "" {
-		return kvs
+// Returns a sequence of all fields (including nested ones) found in the given object.
+// Fields are resolved the same way as for paths (e.g. ignored fields are skipped).
+func findFields(o any, opts ...dotpath.Option) iter.Seq2[reflect.StructField, reflect.Value] {
+	return func(yield func(reflect.StructField, reflect.Value) bool) {
+		walkFields(reflect.ValueOf(o), yield, opts...)
 	}
+}
 
-	// Iterate over the parts of the tag separated by commas.
-	for part := range strings.SplitSeq(tag, ",") {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
+// Walks the fields of the given value recursively.
+// Returns false if the walk has been stopped.
+func walkFields(v reflect.Value, yield func(reflect.StructField, reflect.Value) bool, opts ...dotpath.Option) bool {
+	// If the value is not a struct, skip.
+	v = reflectutil.UnpackValue(v)
+	if v.Kind() != reflect.Struct {
+		return true
+	}
 
-		// Split the part into key and value.
-		kv := strings.SplitN(part, "=", 2)
-		if len(kv) < 2 {
-			// If no value is provided, use "true" as the value.
-			kv = append(kv, "true")
+	for field, value := range dotpath.Fields(v, opts...) {
+		if !yield(field, value) {
+			return false
 		}
 
-		kvs[kv[0]] = kv[1]
+		// Recursively walk the fields of nested structs.
+		if !walkFields(value, yield, opts...) {
+			return false
+		}
 	}
 
-	return kvs
+	return true
 }
 
 // Returns a sequence of file paths and formats found in the given object.
-func findFileFields(o any) iter.Seq2[reflect.Value, string] {
-	v := reflect.ValueOf(o)
-
+func findFileFields(o any, opts ...dotpath.Option) iter.Seq2[reflect.Value, string] {
 	return func(yield func(reflect.Value, string) bool) {
-		// If the value is not a struct, skip.
-		v = reflectutil.UnpackValue(v)
-		if v.Kind() != reflect.Struct {
-			return
-		}
+		for field, value := range findFields(o, opts...) {
+			value := reflectutil.UnpackValue(value)
+
+			// Parse field tag.
+			kvs := parseTag(field.Tag)
 
-		// Iterate over the fields of the struct.
-		for i := 0; i < v.NumField(); i++ {
-			field := v.Type().Field(i)
-			value := reflectutil.UnpackValue(v.Field(i))
+			// If the field is a string with a file tag, yield it.
+			if value.Kind() == reflect.String && kvs["file"] != "" {
+				if !yield(value, kvs["format"]) {
+					return
+				}
+			}
+		}
+	}
+}
 
+// Returns a sequence of fields with names declared by the given tag keys (e.g. "env=DB_URL|PGURL").
+// Multiple names of a key are separated by "|", names of multiple keys are concatenated in order.
+func findNamedFields(o any, keys []string, opts ...dotpath.Option) iter.Seq2[reflect.Value, []string] {
+	return func(yield func(reflect.Value, []string) bool) {
+		for field, value := range findFields(o, opts...) {
 			// Parse field tag.
 			kvs := parseTag(field.Tag)
 
-			switch value.Kind() {
-			case reflect.String:
-				// If the field has a file tag, yield it.
-				if kvs["file"] != "" {
-					if !yield(value, kvs["format"]) {
-						return
-					}
+			names := make([]string, 0)
+			for _, key := range keys {
+				if kvs[key] != "" {
+					names = append(names, strings.Split(kvs[key], "|")...)
+				}
+			}
+
+			if len(names) > 0 {
+				if !yield(value, names) {
+					return
 				}
-			case reflect.Struct:
-				// Recursively find file fields in the nested struct.
-				findFileFields(value.Interface())(yield)
-			default:
-				// Skip other field types.
-				continue
 			}
 		}
 	}
 }
+
+// Returns a sequence of fields marked as secret (e.g. `confless:"secret"`).
+func findSecretFields(o any, opts ...dotpath.Option) iter.Seq[reflect.Value] {
+	return func(yield func(reflect.Value) bool) {
+		for field, value := range findFields(o, opts...) {
+			if parseTag(field.Tag)["secret"] != "" {
+				if !yield(value) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Returns the type of the value with the given name in the given type (nil if unknown).
+func findFieldType(t reflect.Type, name string, opts ...dotpath.Option) reflect.Type {
+	t = unwrapType(t)
+	if t == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		field, ok := dotpath.TypeField(t, name, opts...)
+		if !ok {
+			return nil
+		}
+
+		return field.Type
+	case reflect.Map:
+		return t.Elem()
+	default:
+		return nil
+	}
+}
+
+// Returns the type without pointers.
+func unwrapType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
@@ -0,0 +1,43 @@
+package confless
+
+import (
+	"io"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Decodes a TOML document into generic data (maps, slices and basic values).
+// Local date-times and dates are converted to times in the local time zone (like go-toml does for time.Time),
+// local times to their text (e.g. "07:32:00"), as they have no date.
+func decodeTOML(r io.Reader) (any, error) {
+	var data any
+	err := toml.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertTOMLTimes(data), nil
+}
+
+// Converts the local date-times, dates and times in the decoded data.
+func convertTOMLTimes(data any) any {
+	switch d := data.(type) {
+	case map[string]any:
+		for k, v := range d {
+			d[k] = convertTOMLTimes(v)
+		}
+	case []any:
+		for i, v := range d {
+			d[i] = convertTOMLTimes(v)
+		}
+	case toml.LocalDateTime:
+		return d.AsTime(time.Local)
+	case toml.LocalDate:
+		return d.AsTime(time.Local)
+	case toml.LocalTime:
+		return d.String()
+	}
+
+	return data
+}
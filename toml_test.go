@@ -0,0 +1,54 @@
+package confless
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_decodeTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    any
+		wantErr bool
+	}{
+		{
+			name:    "local date-time",
+			content: "t = 2020-01-01T10:30:00",
+			want:    map[string]any{"t": time.Date(2020, 1, 1, 10, 30, 0, 0, time.Local)},
+		},
+		{
+			name:    "local date in array",
+			content: "dates = [2020-01-01]",
+			want:    map[string]any{"dates": []any{time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)}},
+		},
+		{
+			name:    "local time in table",
+			content: "[alarm]\nat = 07:32:00",
+			want:    map[string]any{"alarm": map[string]any{"at": "07:32:00"}},
+		},
+		{
+			name:    "offset date-time",
+			content: "t = 2020-01-01T10:30:00Z",
+			want:    map[string]any{"t": time.Date(2020, 1, 1, 10, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:    "error for invalid TOML",
+			content: "t = ",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeTOML(strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeTOML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("decodeTOML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package confless
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/codetent/confless/pkg/dotpath"
+)
+
+// Decodes an XML document into generic data (maps, slices and basic values) for the given type.
+// The root element is mapped to the given type, child elements and attributes to its fields.
+// Repeated elements and elements decoded into slices are collected into lists.
+// Text of elements with attributes or children is set to the field tagged with `xml:",chardata"`.
+func decodeXML(r io.Reader, t reflect.Type, opts ...dotpath.Option) (any, error) {
+	dec := xml.NewDecoder(r)
+
+	// Find the root element.
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("missing root element")
+			}
+
+			return nil, err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			return decodeXMLElement(dec, start, t, opts...)
+		}
+	}
+}
+
+// Decodes the element with the given start according to the type it is decoded into.
+func decodeXMLElement(dec *xml.Decoder, start xml.StartElement, t reflect.Type, opts ...dotpath.Option) (any, error) {
+	t = unwrapType(t)
+
+	data := make(map[string]any)
+	for _, attr := range start.Attr {
+		data[attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	children := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("unexpected end of element %s", start.Name.Local)
+			}
+
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			children = true
+
+			name := tok.Name.Local
+			ft := unwrapType(findFieldType(t, name, opts...))
+
+			// Decode elements of slices by the type of their elements.
+			list := ft != nil && (ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array)
+			et := ft
+			if list {
+				et = ft.Elem()
+			}
+
+			value, err := decodeXMLElement(dec, tok, et, opts...)
+			if err != nil {
+				return nil, err
+			}
+
+			// Collect repeated elements into lists.
+			switch existing := data[name].(type) {
+			case nil:
+				if list {
+					value = []any{value}
+				}
+			case []any:
+				value = append(existing, value)
+			default:
+				value = []any{existing, value}
+			}
+
+			data[name] = value
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+
+			// Elements without attributes and children are plain values.
+			if !children && len(data) == 0 && (t == nil || !slices.Contains([]reflect.Kind{reflect.Struct, reflect.Map}, t.Kind())) {
+				return s, nil
+			}
+
+			// Set the text to the character data field.
+			if s != "" && t != nil && t.Kind() == reflect.Struct {
+				for name, field := range dotpath.TypeFields(t, opts...) {
+					if slices.Contains(strings.Split(field.Tag.Get("xml"), ",")[1:], "chardata") {
+						data[name] = s
+						break
+					}
+				}
+			}
+
+			return data, nil
+		}
+	}
+}
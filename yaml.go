@@ -0,0 +1,77 @@
+package confless
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/token"
+)
+
+// Decodes the documents of a YAML stream (separated by "---") into generic data.
+// Empty documents (e.g. only comments or null) are skipped.
+func decodeYAML(r io.Reader) (documents, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+
+	docs := make(documents, 0)
+	for _, doc := range splitYAMLDocuments(string(src)) {
+		var data any
+		err := yaml.NewDecoder(strings.NewReader(doc)).Decode(&data)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		if data != nil {
+			docs = append(docs, data)
+		}
+	}
+
+	return docs, nil
+}
+
+// Splits the YAML stream into its documents by the document markers found by the lexer (not inside scalars).
+// The documents are prefixed by empty lines, so errors report the lines of the stream.
+// Directives and comments before a marker belong to the document it starts, documents without content are dropped
+// (the decoder stops at empty documents).
+func splitYAMLDocuments(src string) []string {
+	// Offsets of the lines.
+	lines := []int{0}
+	for i := range len(src) {
+		if src[i] == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+
+	docs := make([]string, 0)
+	start, header, content, directiveLine := 1, false, false, 0
+	for _, tk := range lexer.Tokenize(src) {
+		line := tk.Position.Line
+
+		switch {
+		case tk.Type == token.DocumentHeaderType:
+			if content {
+				docs = append(docs, strings.Repeat("\n", start-1)+src[lines[start-1]:lines[line-1]])
+			}
+			if content || header {
+				start, content = line, false
+			}
+			header = true
+		case tk.Type == token.DirectiveType:
+			directiveLine = line
+		case tk.Type != token.CommentType && tk.Type != token.DocumentEndType && line != directiveLine:
+			content = true
+		}
+	}
+
+	return append(docs, strings.Repeat("\n", start-1)+src[lines[start-1]:])
+}
@@ -0,0 +1,125 @@
+package confless
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codetent/confless/pkg/dotpath"
+)
+
+func Test_decodeYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    documents
+		wantErr string
+	}{
+		{
+			name:    "single document",
+			content: "name: MyApp\nport: 8080",
+			want:    documents{map[string]any{"name": "MyApp", "port": uint64(8080)}},
+		},
+		{
+			name:    "multiple documents",
+			content: "name: MyApp\n---\nname: Other\n",
+			want:    documents{map[string]any{"name": "MyApp"}, map[string]any{"name": "Other"}},
+		},
+		{
+			name:    "leading marker and directive",
+			content: "%YAML 1.2\n---\nname: MyApp\n---\nname: Other",
+			want:    documents{map[string]any{"name": "MyApp"}, map[string]any{"name": "Other"}},
+		},
+		{
+			name:    "null documents are skipped",
+			content: "---\nname: MyApp\n--- ~\n---\nnull\n---\nname: Other\n...\n",
+			want:    documents{map[string]any{"name": "MyApp"}, map[string]any{"name": "Other"}},
+		},
+		{
+			name:    "comment-only document in the middle",
+			content: "name: a\n---\n# comment\n---\nname: b",
+			want:    documents{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+		},
+		{
+			name:    "empty documents in the middle",
+			content: "%YAML 1.2\n---\nname: a\n---\n---\n\n---\nname: b\n...\n",
+			want:    documents{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+		},
+		{
+			name:    "leading empty document",
+			content: "---\n---\nname: a",
+			want:    documents{map[string]any{"name": "a"}},
+		},
+		{
+			name:    "content after marker",
+			content: "--- {name: MyApp}\n--- {name: Other}",
+			want:    documents{map[string]any{"name": "MyApp"}, map[string]any{"name": "Other"}},
+		},
+		{
+			name:    "marker in block scalar",
+			content: "text: |\n  ---\n  a\n",
+			want:    documents{map[string]any{"text": "---\na\n"}},
+		},
+		{
+			name:    "empty stream",
+			content: "",
+			want:    documents{},
+		},
+		{
+			name:    "error reports line in stream after empty document",
+			content: "name: MyApp\n---\n# comment\n---\nname: Other\nports: [1\n",
+			wantErr: "[6:",
+		},
+		{
+			name:    "error reports line in stream",
+			content: "name: MyApp\n---\nname: Other\nports: [1\n",
+			wantErr: "[4:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeYAML(strings.NewReader(tt.content))
+			if err != nil {
+				if tt.wantErr == "" || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeYAML() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if tt.wantErr != "" {
+				t.Fatal("decodeYAML() succeeded unexpectedly")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeYAML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_profile_selects(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile profile
+		opts    []dotpath.Option
+		doc     any
+		want    bool
+	}{
+		{name: "without key", profile: profile{}, doc: map[string]any{"profile": "dev"}, want: true},
+		{name: "document without key", profile: profile{key: "profile", value: "prod"}, doc: map[string]any{"name": "MyApp"}, want: true},
+		{name: "matching value", profile: profile{key: "profile", value: "prod"}, doc: map[string]any{"profile": "prod"}, want: true},
+		{name: "other value", profile: profile{key: "profile", value: "prod"}, doc: map[string]any{"profile": "dev"}, want: false},
+		{name: "list containing value", profile: profile{key: "profile", value: "prod"}, doc: map[string]any{"profile": []any{"staging", "prod"}}, want: true},
+		{name: "list without value", profile: profile{key: "profile", value: "prod"}, doc: map[string]any{"profile": []any{"dev"}}, want: false},
+		{name: "empty profile", profile: profile{key: "profile"}, doc: map[string]any{"profile": "dev"}, want: false},
+		{name: "no mapping", profile: profile{key: "profile", value: "prod"}, doc: []any{"a"}, want: true},
+		{name: "key differing in case", profile: profile{key: "profile", value: "prod"}, doc: map[string]any{"Profile": "dev"}, want: false},
+		{name: "key differing in case with exact matching", profile: profile{key: "profile", value: "prod"}, opts: []dotpath.Option{dotpath.WithMatching(dotpath.MatchExact)}, doc: map[string]any{"Profile": "dev"}, want: true},
+		{name: "normalized key", profile: profile{key: "app_profile", value: "prod"}, opts: []dotpath.Option{dotpath.WithMatching(dotpath.MatchNormalized)}, doc: map[string]any{"appProfile": "prod"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.profile.selects(tt.doc, tt.opts...); got != tt.want {
+				t.Errorf("selects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}